@@ -1,14 +1,32 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"log/slog"
 	"mini-oms-backend/internal/config"
 	"mini-oms-backend/internal/db"
+	"mini-oms-backend/internal/mailer"
+	"mini-oms-backend/internal/metrics"
 	"mini-oms-backend/internal/middlewares"
 	"mini-oms-backend/internal/modules/auth"
+	"mini-oms-backend/internal/modules/category"
+	"mini-oms-backend/internal/modules/health"
+	"mini-oms-backend/internal/modules/idempotency"
+	"mini-oms-backend/internal/modules/invitation"
 	"mini-oms-backend/internal/modules/order"
 	"mini-oms-backend/internal/modules/payment"
 	"mini-oms-backend/internal/modules/product"
+	"mini-oms-backend/internal/modules/refund"
+	"mini-oms-backend/internal/modules/role"
+	"mini-oms-backend/internal/policy"
+	"mini-oms-backend/internal/utils"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
@@ -23,6 +41,7 @@ func main() {
 
 	// Load configuration
 	cfg := config.Load()
+	slog.SetDefault(utils.NewLogger(cfg.LogLevel, cfg.LogFormat))
 	cfg.LogConfig()
 
 	// Connect to database
@@ -30,83 +49,282 @@ func main() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
-	// Run Seeder & Fixer
+	// Subcommand: migrate (up, down, status, to)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := db.RunMigrateCommand(os.Args[2:]); err != nil {
+			log.Fatal("Migration failed: ", err)
+		}
+		return
+	}
+
+	// Refuse to run against an unmigrated schema
+	if err := db.EnsureMigrated(); err != nil {
+		log.Fatal("Schema check failed: ", err)
+	}
+
+	// Expose connection pool statistics
+	if sqlDB, err := db.GetDB().DB(); err == nil {
+		if err := metrics.RegisterDBStats(sqlDB); err != nil {
+			log.Fatal("Failed to register DB metrics: ", err)
+		}
+	}
+
+	// Run Seeder
 	db.Seed(db.GetDB())
 
 	// Initialize Echo
 	e := echo.New()
+	e.Validator = utils.NewRequestValidator()
+	e.HTTPErrorHandler = utils.HTTPErrorHandler
+
+	// Client IP used by rate limits and login throttling. X-Forwarded-For is only trusted
+	// behind a reverse proxy, and then only from private network addresses.
+	if cfg.TrustProxyHeaders {
+		e.IPExtractor = echo.ExtractIPFromXFFHeader()
+	} else {
+		e.IPExtractor = echo.ExtractIPDirect()
+	}
 
 	// Middleware
-	e.Use(middleware.Logger())
+	e.Use(middlewares.RequestIDMiddleware())
+	e.Use(middlewares.RequestLoggerMiddleware())
+	e.Use(middlewares.MetricsMiddleware())
 	e.Use(middleware.Recover())
+	e.Use(middlewares.TimeoutMiddleware(time.Duration(cfg.RequestTimeoutSeconds)*time.Second, cfg.RouteTimeouts))
 
 	// CORS configuration for production
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"}, // Allow all origins (frontend dari Vercel/Render/dll)
-		AllowMethods: []string{echo.GET, echo.POST, echo.PUT, echo.DELETE, echo.PATCH, echo.OPTIONS},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
+		AllowOrigins:  []string{"*"}, // Allow all origins (frontend dari Vercel/Render/dll)
+		AllowMethods:  []string{echo.GET, echo.POST, echo.PUT, echo.DELETE, echo.PATCH, echo.OPTIONS},
+		AllowHeaders:  []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, echo.HeaderXRequestID, middlewares.HeaderIdempotencyKey},
+		ExposeHeaders: []string{echo.HeaderXRequestID, middlewares.HeaderIdempotentReplayed},
 	}))
 
 	// Initialize repositories
 	authRepo := auth.NewRepository(db.GetDB())
+	invitationRepo := invitation.NewRepository(db.GetDB())
+	categoryRepo := category.NewRepository(db.GetDB())
 	productRepo := product.NewRepository(db.GetDB())
 	orderRepo := order.NewRepository(db.GetDB())
 	paymentRepo := payment.NewRepository(db.GetDB())
+	refundRepo := refund.NewRepository(db.GetDB())
+	idempotencyRepo := idempotency.NewRepository(db.GetDB())
+	roleRepo := role.NewRepository(db.GetDB())
+
+	// Outgoing email (password reset and verification links)
+	mail, err := mailer.New(cfg)
+	if err != nil {
+		log.Fatal("Invalid mail configuration: ", err)
+	}
 
 	// Initialize services
-	authService := auth.NewService(authRepo, cfg)
+	authService := auth.NewService(authRepo, cfg, mail)
+	invitationService := invitation.NewService(invitationRepo, cfg)
+	categoryService := category.NewService(categoryRepo)
 	productService := product.NewService(productRepo)
-	orderService := order.NewService(orderRepo, db.GetDB())
+	orderService := order.NewService(orderRepo)
 	paymentService := payment.NewService(paymentRepo)
+	refundService := refund.NewService(refundRepo)
+	idempotencyService := idempotency.NewService(idempotencyRepo, time.Duration(cfg.IdempotencyKeyTTLHours)*time.Hour)
+	roleService := role.NewService(roleRepo)
 
 	// Initialize handlers
 	authHandler := auth.NewHandler(authService)
+	invitationHandler := invitation.NewHandler(invitationService)
+	categoryHandler := category.NewHandler(categoryService)
 	productHandler := product.NewHandler(productService)
 	orderHandler := order.NewHandler(orderService)
 	paymentHandler := payment.NewHandler(paymentService)
+	refundHandler := refund.NewHandler(refundService)
+	roleHandler := role.NewHandler(roleService)
+	schemaVersion, err := db.LatestMigrationVersion()
+	if err != nil {
+		log.Fatal("Failed to read migrations: ", err)
+	}
+	healthHandler := health.NewHandler(health.NewService(db.GetDB(), schemaVersion))
+
+	// Health probes
+	e.GET("/healthz", healthHandler.Liveness)
+	e.GET("/readyz", healthHandler.Readiness)
 
 	// Routes
 	api := e.Group("/api")
 
-	// Public routes
-	api.POST("/auth/register", authHandler.Register)
-	api.POST("/auth/login", authHandler.Login)
+	// Public auth routes, rate limited per client IP
+	authLimit := middlewares.RateLimitMiddleware(cfg.AuthRateLimitPerMinute, cfg.AuthRateLimitBurst)
+	public := api.Group("/auth", authLimit)
+	public.POST("/register", authHandler.Register)
+	public.POST("/login", authHandler.Login)
+	public.POST("/refresh", authHandler.Refresh)
+	public.POST("/password/forgot", authHandler.ForgotPassword)
+	public.POST("/password/reset", authHandler.ResetPassword)
+	public.POST("/email/verify", authHandler.VerifyEmail)
+	public.POST("/email/resend", authHandler.ResendVerification)
+	public.POST("/email/change/confirm", authHandler.ConfirmEmailChange)
+	api.POST("/invitations/accept", invitationHandler.Accept, authLimit)
+
+	// Public category routes (anyone can view)
+	api.GET("/categories", categoryHandler.GetAll)
+	api.GET("/categories/:id", categoryHandler.GetByID)
 
 	// Public product routes (anyone can view)
 	api.GET("/products", productHandler.GetAll)
+	api.GET("/products/search", productHandler.Search)
 	api.GET("/products/:id", productHandler.GetByID)
 
 	// Protected routes (require JWT)
 	protected := api.Group("")
-	protected.Use(middlewares.JWTMiddleware(cfg))
+	protected.Use(middlewares.JWTMiddleware(cfg, authService))
+
+	// Retried creations with the same Idempotency-Key header replay the first response
+	idempotent := middlewares.IdempotencyMiddleware(idempotencyService)
+
+	// Auth session routes (protected)
+	protected.POST("/auth/logout", authHandler.Logout)
+
+	// Profile of the current user (protected); routes checking the current password are rate limited
+	protected.GET("/me", authHandler.GetProfile)
+	protected.PUT("/me", authHandler.UpdateProfile)
+	protected.DELETE("/me", authHandler.DeleteAccount, authLimit)
+	protected.POST("/me/email", authHandler.ChangeEmail, authLimit)
+	protected.PUT("/me/password", authHandler.ChangePassword, authLimit)
 
 	// Order routes (protected)
-	protected.GET("/orders", orderHandler.GetAll)      // User sees own, Admin sees all
-	protected.GET("/orders/:id", orderHandler.GetByID) // User sees own, Admin sees all
-	protected.POST("/orders", orderHandler.Create)
+	protected.GET("/orders", orderHandler.GetAll)      // User sees own, orders:read sees all
+	protected.GET("/orders/:id", orderHandler.GetByID) // User sees own, orders:read sees all
+	protected.POST("/orders", orderHandler.Create, idempotent)
 	protected.POST("/orders/:id/cancel", orderHandler.Cancel)
 
 	// Payment routes (protected)
-	protected.POST("/payments", paymentHandler.Create)
+	protected.POST("/payments", paymentHandler.Create, idempotent)
 	protected.GET("/payments/order/:orderId", paymentHandler.GetByOrderID)
 
-	// Admin-only routes
-	admin := api.Group("")
-	admin.Use(middlewares.JWTMiddleware(cfg))
-	admin.Use(middlewares.AdminOnlyMiddleware())
+	// Staff routes, each requiring a permission of the user's role
+	staff := api.Group("")
+	staff.Use(middlewares.JWTMiddleware(cfg, authService))
+	requires := middlewares.RequirePermission
 
 	// Admin stats
-	admin.GET("/admin/stats", orderHandler.GetStats)
+	staff.GET("/admin/stats", orderHandler.GetStats, requires(policy.PermStatsRead))
+
+	// Admin invitations
+	staff.GET("/admin/invitations", invitationHandler.GetAll, requires(policy.PermInvitationsManage))
+	staff.POST("/admin/invitations", invitationHandler.Create, requires(policy.PermInvitationsManage))
+	staff.DELETE("/admin/invitations/:id", invitationHandler.Revoke, requires(policy.PermInvitationsManage))
+
+	// Roles and role assignment
+	staff.GET("/admin/permissions", roleHandler.GetPermissions, requires(policy.PermRolesManage))
+	staff.GET("/admin/roles", roleHandler.GetAll, requires(policy.PermRolesManage))
+	staff.GET("/admin/roles/:name", roleHandler.GetByName, requires(policy.PermRolesManage))
+	staff.POST("/admin/roles", roleHandler.Create, requires(policy.PermRolesManage))
+	staff.PUT("/admin/roles/:name", roleHandler.Update, requires(policy.PermRolesManage))
+	staff.DELETE("/admin/roles/:name", roleHandler.Delete, requires(policy.PermRolesManage))
+	staff.PUT("/admin/users/:id/role", authHandler.AssignRole, requires(policy.PermRolesManage))
+
+	// User accounts
+	staff.POST("/admin/users/:id/unlock", authHandler.Unlock, requires(policy.PermUsersManage))
+
+	// Category management
+	staff.POST("/categories", categoryHandler.Create, requires(policy.PermCatalogWrite))
+	staff.PUT("/categories/:id", categoryHandler.Update, requires(policy.PermCatalogWrite))
+	staff.DELETE("/categories/:id", categoryHandler.Delete, requires(policy.PermCatalogWrite))
 
-	// Product management (admin only)
-	admin.POST("/products", productHandler.Create)
-	admin.PUT("/products/:id", productHandler.Update)
-	admin.DELETE("/products/:id", productHandler.Delete)
+	// Order fulfillment
+	staff.POST("/orders/:id/ship", orderHandler.Ship, requires(policy.PermOrdersFulfill))
+	staff.POST("/orders/:id/complete", orderHandler.Complete, requires(policy.PermOrdersFulfill))
 
-	// Payment verification (admin only)
-	admin.POST("/payments/:id/verify", paymentHandler.Verify)
+	// Product management
+	staff.POST("/products", productHandler.Create, requires(policy.PermCatalogWrite))
+	staff.PUT("/products/:id", productHandler.Update, requires(policy.PermCatalogWrite))
+	staff.DELETE("/products/:id", productHandler.Delete, requires(policy.PermCatalogWrite))
+
+	// Payment verification & rejection
+	staff.POST("/payments/:id/verify", paymentHandler.Verify, requires(policy.PermPaymentsVerify))
+	staff.POST("/payments/:id/reject", paymentHandler.Reject, requires(policy.PermPaymentsVerify))
+
+	// Refunds
+	staff.POST("/payments/:id/refunds", refundHandler.Create, requires(policy.PermRefundsManage))
+	staff.GET("/refunds", refundHandler.GetAll, requires(policy.PermRefundsManage))
+	staff.GET("/refunds/:id", refundHandler.GetByID, requires(policy.PermRefundsManage))
+	staff.POST("/refunds/:id/complete", refundHandler.Complete, requires(policy.PermRefundsManage))
+	staff.POST("/refunds/:id/fail", refundHandler.Fail, requires(policy.PermRefundsManage))
+
+	// Background workers
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	var workers []<-chan struct{}
+	if cfg.OrderPaymentTTLMinutes > 0 {
+		expiryWorker := order.NewExpiryWorker(
+			orderService,
+			time.Duration(cfg.OrderPaymentTTLMinutes)*time.Minute,
+			time.Duration(cfg.OrderExpiryCheckIntervalSeconds)*time.Second,
+		)
+		workers = append(workers, expiryWorker.Start(workerCtx))
+	}
+	workers = append(workers, idempotency.NewPurgeWorker(idempotencyService, time.Hour).Start(workerCtx))
 
 	// Start server
-	log.Printf("Server starting on port %s", cfg.Port)
-	e.Logger.Fatal(e.Start(":" + cfg.Port))
+	e.HideBanner = true
+	e.Server.ReadTimeout = time.Duration(cfg.ServerReadTimeoutSeconds) * time.Second
+	e.Server.WriteTimeout = time.Duration(cfg.ServerWriteTimeoutSeconds) * time.Second
+	e.Server.IdleTimeout = time.Duration(cfg.ServerIdleTimeoutSeconds) * time.Second
+
+	serverErr := make(chan error, 2) // API and metrics listeners
+	go func() {
+		slog.Info("Server starting", "port", cfg.Port)
+		serverErr <- e.Start(":" + cfg.Port)
+	}()
+
+	// Prometheus metrics are served on their own listener, so the public port never exposes them
+	var metricsServer *http.Server
+	if cfg.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		metricsServer = &http.Server{Addr: cfg.MetricsAddr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+		go func() {
+			slog.Info("Metrics server starting", "addr", cfg.MetricsAddr)
+			serverErr <- metricsServer.ListenAndServe()
+		}()
+	}
+
+	// Wait for SIGTERM (deploys) or SIGINT (Ctrl+C)
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Server failed: ", err)
+		}
+	case <-signalCtx.Done():
+		slog.Info("Shutdown signal received, draining in-flight requests")
+	}
+	stopSignals()
+
+	// Stop accepting connections and let in-flight requests (and their transactions) finish
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ServerShutdownTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Server did not drain in time", "error", err)
+	}
+	if metricsServer != nil {
+		metricsServer.Shutdown(shutdownCtx)
+	}
+
+	// Stop background workers; an interrupted sweep rolls back its current order
+	stopWorkers()
+	for _, done := range workers {
+		select {
+		case <-done:
+		case <-shutdownCtx.Done():
+			slog.Error("Background worker did not stop in time")
+		}
+	}
+
+	if sqlDB, err := db.GetDB().DB(); err == nil {
+		sqlDB.Close()
+	}
+	slog.Info("Server stopped")
 }
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"mini-oms-backend/internal/config"
+	"mini-oms-backend/internal/db"
+	"mini-oms-backend/internal/mailer"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/modules/auth"
+	"mini-oms-backend/internal/modules/order"
+	"mini-oms-backend/internal/modules/payment"
+	"mini-oms-backend/internal/policy"
+	"os"
+
+	"github.com/google/uuid"
+)
+
+// app wires the module services used by omsctl
+type app struct {
+	authRepo       auth.Repository
+	authService    *auth.Service
+	orderService   *order.Service
+	paymentService *payment.Service
+}
+
+func newApp(cfg *config.Config) *app {
+	authRepo := auth.NewRepository(db.GetDB())
+	orderRepo := order.NewRepository(db.GetDB())
+	paymentRepo := payment.NewRepository(db.GetDB())
+
+	// omsctl sends no email; accounts it creates are already verified
+	mail := mailer.NewLogMailer(cfg.MailFrom, "")
+
+	return &app{
+		authRepo:       authRepo,
+		authService:    auth.NewService(authRepo, cfg, mail),
+		orderService:   order.NewService(orderRepo),
+		paymentService: payment.NewService(paymentRepo),
+	}
+}
+
+// resolveActor maps -as <email> to an admin, or the system actor when empty.
+// Operators have shell access, so the actor holds every permission either way.
+func (a *app) resolveActor(ctx context.Context, email string, required bool) (policy.Actor, error) {
+	if email == "" {
+		if required {
+			return policy.Actor{}, errors.New("-as <admin email> is required for this command")
+		}
+		return policy.Admin(models.SystemActorID), nil
+	}
+
+	user, err := a.authRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return policy.Actor{}, fmt.Errorf("actor %s not found", email)
+	}
+	if !user.IsAdmin() {
+		return policy.Actor{}, fmt.Errorf("actor %s is not an admin", email)
+	}
+	return policy.Admin(user.ID), nil
+}
+
+func (a *app) runUser(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	fs := flag.NewFlagSet("user "+args[0], flag.ExitOnError)
+	name := fs.String("name", "", "full name")
+	email := fs.String("email", "", "account email")
+	password := fs.String("password", "", "password (min 6 characters)")
+	role := fs.String("role", "", "role name, e.g. user, admin, support (create defaults to user)")
+	fs.Parse(args[1:])
+
+	if *email == "" {
+		return errors.New("-email is required")
+	}
+
+	actor := models.SystemActorID
+
+	switch args[0] {
+	case "create":
+		if *role == "" {
+			*role = models.RoleUser
+		}
+		user, err := a.authService.CreateUser(ctx, actor, *name, *email, *password, *role)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created %s user %s (%s)\n", user.Role, user.Email, user.ID)
+
+	case "disable", "enable":
+		user, err := a.authService.SetDisabled(ctx, actor, *email, args[0] == "disable")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("User %s %sd\n", user.Email, args[0])
+
+	case "set-role":
+		// Never fall back to a default here: that would silently demote the account
+		if *role == "" {
+			return errors.New("-role is required")
+		}
+		user, err := a.authService.SetRole(ctx, actor, *email, *role)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("User %s is now %s (existing sessions revoked)\n", user.Email, user.Role)
+
+	case "reset-password":
+		if err := a.authService.ResetPassword(ctx, actor, *email, *password); err != nil {
+			return err
+		}
+		fmt.Printf("Password for %s reset (existing sessions revoked)\n", *email)
+
+	case "unlock":
+		user, err := a.authRepo.FindByEmail(ctx, *email)
+		if err != nil {
+			return fmt.Errorf("user %s not found", *email)
+		}
+		if _, err := a.authService.Unlock(ctx, actor, user.ID); err != nil {
+			return err
+		}
+		fmt.Printf("User %s unlocked (failed logins cleared)\n", user.Email)
+
+	default:
+		return errors.New(usage)
+	}
+	return nil
+}
+
+func (a *app) runOrder(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	fs := flag.NewFlagSet("order "+args[0], flag.ExitOnError)
+	idFlag := fs.String("id", "", "order ID")
+	as := fs.String("as", "", "admin email recorded as the actor")
+	fs.Parse(args[1:])
+
+	orderID, err := uuid.Parse(*idFlag)
+	if err != nil {
+		return errors.New("-id must be a valid order ID")
+	}
+
+	actor, err := a.resolveActor(ctx, *as, false)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "cancel":
+		if err := a.orderService.CancelOrder(ctx, orderID, actor); err != nil {
+			return err
+		}
+		fmt.Printf("Order %s canceled\n", orderID)
+
+	case "reopen":
+		o, err := a.orderService.ReopenOrder(ctx, orderID, actor)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Order %s re-opened, status %s\n", o.OrderNumber, o.Status)
+
+	default:
+		return errors.New(usage)
+	}
+	return nil
+}
+
+func (a *app) runPayment(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	fs := flag.NewFlagSet("payment "+args[0], flag.ExitOnError)
+	idFlag := fs.String("id", "", "payment ID")
+	reason := fs.String("reason", "", "rejection reason")
+	as := fs.String("as", "", "admin email recorded as the verifier")
+	fs.Parse(args[1:])
+
+	paymentID, err := uuid.Parse(*idFlag)
+	if err != nil {
+		return errors.New("-id must be a valid payment ID")
+	}
+
+	// Payments store the verifier, so a real admin account is required
+	actor, err := a.resolveActor(ctx, *as, true)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "verify":
+		p, err := a.paymentService.VerifyPayment(ctx, paymentID, actor)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Payment %s verified, status %s\n", p.PaymentNumber, p.Status)
+
+	case "reject":
+		p, err := a.paymentService.RejectPayment(ctx, paymentID, actor.UserID, &payment.RejectPaymentRequest{Reason: *reason})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Payment %s rejected\n", p.PaymentNumber)
+
+	default:
+		return errors.New(usage)
+	}
+	return nil
+}
+
+func (a *app) runStats(ctx context.Context) error {
+	stats, err := a.orderService.GetStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(stats)
+}
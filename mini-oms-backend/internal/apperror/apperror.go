@@ -0,0 +1,159 @@
+// Package apperror defines the domain error kinds shared by all modules and how they
+// map to HTTP statuses and machine-readable codes.
+//
+// Services return errors built with the constructors below (or types that match a kind
+// through an Is method, like order.TransitionError); handlers return them unchanged and
+// utils.HTTPErrorHandler renders the response. Match a kind with errors.Is:
+//
+//	errors.Is(err, apperror.ErrNotFound)
+package apperror
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Error kinds
+var (
+	ErrInvalidInput      = errors.New("invalid input")
+	ErrUnauthorized      = errors.New("unauthorized")
+	ErrForbidden         = errors.New("forbidden")
+	ErrNotFound          = errors.New("not found")
+	ErrConflict          = errors.New("conflict")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrInvalidTransition = errors.New("invalid status transition")
+	ErrTooManyRequests   = errors.New("too many requests")
+)
+
+// Codes returned in the `code` field of error responses
+const (
+	CodeInvalidInput       = "INVALID_INPUT"
+	CodeValidationFailed   = "VALIDATION_FAILED"
+	CodeUnauthorized       = "UNAUTHORIZED"
+	CodeForbidden          = "FORBIDDEN"
+	CodeNotFound           = "NOT_FOUND"
+	CodeMethodNotAllowed   = "METHOD_NOT_ALLOWED"
+	CodeConflict           = "CONFLICT"
+	CodeInsufficientStock  = "INSUFFICIENT_STOCK"
+	CodeInvalidTransition  = "INVALID_STATUS_TRANSITION"
+	CodeTooManyRequests    = "TOO_MANY_REQUESTS"
+	CodeInternal           = "INTERNAL_ERROR"
+	CodeServiceUnavailable = "SERVICE_UNAVAILABLE"
+	CodeTimeout            = "REQUEST_TIMEOUT"
+)
+
+var kinds = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{ErrInvalidInput, http.StatusBadRequest, CodeInvalidInput},
+	{ErrUnauthorized, http.StatusUnauthorized, CodeUnauthorized},
+	{ErrForbidden, http.StatusForbidden, CodeForbidden},
+	{ErrNotFound, http.StatusNotFound, CodeNotFound},
+	{ErrInsufficientStock, http.StatusConflict, CodeInsufficientStock},
+	{ErrInvalidTransition, http.StatusConflict, CodeInvalidTransition},
+	{ErrConflict, http.StatusConflict, CodeConflict},
+	{ErrTooManyRequests, http.StatusTooManyRequests, CodeTooManyRequests},
+}
+
+// Error is a domain error whose message is safe to show to clients
+type Error struct {
+	kind    error
+	message string
+}
+
+func (e *Error) Error() string { return e.message }
+
+// Is reports whether target is this error's kind, so errors.Is(err, ErrNotFound) matches
+func (e *Error) Is(target error) bool { return target == e.kind }
+
+func InvalidInput(message string) *Error      { return &Error{ErrInvalidInput, message} }
+func Unauthorized(message string) *Error      { return &Error{ErrUnauthorized, message} }
+func Forbidden(message string) *Error         { return &Error{ErrForbidden, message} }
+func NotFound(message string) *Error          { return &Error{ErrNotFound, message} }
+func Conflict(message string) *Error          { return &Error{ErrConflict, message} }
+func InsufficientStock(message string) *Error { return &Error{ErrInsufficientStock, message} }
+func InvalidTransition(message string) *Error { return &Error{ErrInvalidTransition, message} }
+func TooManyRequests(message string) *Error   { return &Error{ErrTooManyRequests, message} }
+
+// Detailer is implemented by errors that carry structured details for the `errors` field
+type Detailer interface {
+	ErrorDetails() map[string]interface{}
+}
+
+// RetryError tells the client when it may try again; it is rendered with a Retry-After header
+type RetryError struct {
+	Err   error
+	After time.Duration
+}
+
+// RetryAfter wraps err, typically of kind ErrTooManyRequests, with a retry delay
+func RetryAfter(err error, after time.Duration) *RetryError {
+	return &RetryError{Err: err, After: after}
+}
+
+func (e *RetryError) Error() string { return e.Err.Error() }
+func (e *RetryError) Unwrap() error { return e.Err }
+
+// Seconds is the delay rounded up to whole seconds, at least 1
+func (e *RetryError) Seconds() int {
+	return max(1, int(math.Ceil(e.After.Seconds())))
+}
+
+// ErrorDetails implements Detailer
+func (e *RetryError) ErrorDetails() map[string]interface{} {
+	return map[string]interface{}{"retry_after_seconds": e.Seconds()}
+}
+
+// MapNotFound returns notFound when err is gorm.ErrRecordNotFound and err otherwise,
+// so database failures are not reported as missing records
+func MapNotFound(err, notFound error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return notFound
+	}
+	return err
+}
+
+// Lookup returns the HTTP status and code for a domain error. ok is false for errors
+// of no known kind, which must be treated as internal errors.
+func Lookup(err error) (status int, code string, ok bool) {
+	for _, k := range kinds {
+		if errors.Is(err, k.kind) {
+			return k.status, k.code, true
+		}
+	}
+	return http.StatusInternalServerError, CodeInternal, false
+}
+
+// CodeForStatus is the code used for responses that were not built from a domain error
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidInput
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusTooManyRequests:
+		return CodeTooManyRequests
+	case http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeTimeout
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeInvalidInput
+}
@@ -1,9 +1,13 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -11,6 +15,24 @@ type Config struct {
 	Port string
 	Env  string
 
+	// HTTP server timeouts in seconds. The write timeout must outlast the longest route timeout.
+	ServerReadTimeoutSeconds     int
+	ServerWriteTimeoutSeconds    int
+	ServerIdleTimeoutSeconds     int
+	ServerShutdownTimeoutSeconds int // how long in-flight requests get to finish on SIGTERM
+
+	// Address of the internal listener serving /metrics, separate from the public port (empty disables)
+	MetricsAddr string
+
+	// Logging
+	LogLevel      string // debug, info, warn or error
+	LogFormat     string // json or text
+	DBSlowQueryMs int    // queries slower than this are logged at warn level (0 disables)
+
+	// Request timeouts
+	RequestTimeoutSeconds int                      // 0 disables the default timeout
+	RouteTimeouts         map[string]time.Duration // keyed by "METHOD /route/pattern"
+
 	// Database
 	DBHost     string
 	DBPort     string
@@ -20,15 +42,77 @@ type Config struct {
 	DBSSLMode  string
 
 	// JWT
-	JWTSecret      string
-	JWTExpiryHours int
+	JWTSecret               string
+	JWTExpiryHours          int
+	RefreshTokenExpiryHours int
+
+	// Invitations
+	InvitationExpiryHours int
+
+	// Account emails
+	AppBaseURL                     string // Frontend URL used in links sent by email
+	PasswordResetTokenTTLMinutes   int
+	EmailVerificationTokenTTLHours int
+	RequireEmailVerification       bool // Block login until the email address is verified
+
+	// Login throttling. Failures older than the window are forgotten; 0 disables a limit.
+	LoginFailureWindowMinutes int
+	LoginFreeAttempts         int // failures per account before each attempt is delayed
+	LoginMaxFailures          int // failures per account that lock it
+	LoginLockoutMinutes       int
+	LoginIPMaxFailures        int // failures per client IP, across accounts, that block it
+
+	// Rate limit of the public auth routes, per client IP
+	AuthRateLimitPerMinute int // 0 disables
+	AuthRateLimitBurst     int
+
+	// Use X-Forwarded-For (set by trusted proxies on private networks) as the client IP
+	TrustProxyHeaders bool
+
+	// Mail delivery
+	MailDriver   string // smtp or log
+	MailFrom     string
+	MailDir      string // log driver: also write each message to this directory as an .eml file
+	SMTPHost     string
+	SMTPPort     int // STARTTLS is used when the server offers it
+	SMTPUsername string
+	SMTPPassword string
+
+	// Orders
+	OrderPaymentTTLMinutes          int // 0 disables auto-cancel of unpaid orders
+	OrderExpiryCheckIntervalSeconds int
+
+	// Idempotency-Key responses are replayed for retries within this window
+	IdempotencyKeyTTLHours int
 }
 
 func Load() *Config {
+	env := getEnv("ENV", "development")
+	defaultLogFormat := "text"
+	if env == "production" {
+		defaultLogFormat = "json"
+	}
+
 	return &Config{
 		// Server
 		Port: getEnv("PORT", "8080"),
-		Env:  getEnv("ENV", "development"),
+		Env:  env,
+
+		ServerReadTimeoutSeconds:     getEnvAsInt("SERVER_READ_TIMEOUT_SECONDS", 15),
+		ServerWriteTimeoutSeconds:    getEnvAsInt("SERVER_WRITE_TIMEOUT_SECONDS", 60),
+		ServerIdleTimeoutSeconds:     getEnvAsInt("SERVER_IDLE_TIMEOUT_SECONDS", 120),
+		ServerShutdownTimeoutSeconds: getEnvAsInt("SERVER_SHUTDOWN_TIMEOUT_SECONDS", 30),
+
+		MetricsAddr: getEnv("METRICS_ADDR", ":9090"),
+
+		// Logging
+		LogLevel:      getEnv("LOG_LEVEL", "info"),
+		LogFormat:     getEnv("LOG_FORMAT", defaultLogFormat),
+		DBSlowQueryMs: getEnvAsInt("DB_SLOW_QUERY_MS", 200),
+
+		// Request timeouts
+		RequestTimeoutSeconds: getEnvAsInt("REQUEST_TIMEOUT_SECONDS", 15),
+		RouteTimeouts:         getEnvAsRouteTimeouts("ROUTE_TIMEOUTS", "GET /api/admin/stats=30s"),
 
 		// Database
 		DBHost:     getEnv("DB_HOST", "localhost"),
@@ -39,8 +123,47 @@ func Load() *Config {
 		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
 
 		// JWT
-		JWTSecret:      getEnv("JWT_SECRET", "your-secret-key-change-this"),
-		JWTExpiryHours: getEnvAsInt("JWT_EXPIRY_HOURS", 24),
+		JWTSecret:               getEnv("JWT_SECRET", "your-secret-key-change-this"),
+		JWTExpiryHours:          getEnvAsInt("JWT_EXPIRY_HOURS", 24),
+		RefreshTokenExpiryHours: getEnvAsInt("REFRESH_TOKEN_EXPIRY_HOURS", 168),
+
+		// Invitations
+		InvitationExpiryHours: getEnvAsInt("INVITATION_EXPIRY_HOURS", 72),
+
+		// Account emails
+		AppBaseURL:                     strings.TrimRight(getEnv("APP_BASE_URL", "http://localhost:3000"), "/"),
+		PasswordResetTokenTTLMinutes:   getEnvAsInt("PASSWORD_RESET_TOKEN_TTL_MINUTES", 60),
+		EmailVerificationTokenTTLHours: getEnvAsInt("EMAIL_VERIFICATION_TOKEN_TTL_HOURS", 48),
+		RequireEmailVerification:       getEnvAsBool("REQUIRE_EMAIL_VERIFICATION", false),
+
+		// Login throttling
+		LoginFailureWindowMinutes: getEnvAsInt("LOGIN_FAILURE_WINDOW_MINUTES", 15),
+		LoginFreeAttempts:         getEnvAsInt("LOGIN_FREE_ATTEMPTS", 3),
+		LoginMaxFailures:          getEnvAsInt("LOGIN_MAX_FAILURES", 10),
+		LoginLockoutMinutes:       getEnvAsInt("LOGIN_LOCKOUT_MINUTES", 15),
+		LoginIPMaxFailures:        getEnvAsInt("LOGIN_IP_MAX_FAILURES", 50),
+
+		// Rate limits
+		AuthRateLimitPerMinute: getEnvAsInt("AUTH_RATE_LIMIT_PER_MINUTE", 30),
+		AuthRateLimitBurst:     getEnvAsInt("AUTH_RATE_LIMIT_BURST", 10),
+
+		TrustProxyHeaders: getEnvAsBool("TRUST_PROXY_HEADERS", false),
+
+		// Mail delivery
+		MailDriver:   getEnv("MAIL_DRIVER", "log"),
+		MailFrom:     getEnv("MAIL_FROM", "Mini OMS <no-reply@localhost>"),
+		MailDir:      getEnv("MAIL_DIR", ""),
+		SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+		SMTPPort:     getEnvAsInt("SMTP_PORT", 587),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+
+		// Orders
+		OrderPaymentTTLMinutes:          getEnvAsInt("ORDER_PAYMENT_TTL_MINUTES", 1440),
+		OrderExpiryCheckIntervalSeconds: getEnvAsInt("ORDER_EXPIRY_CHECK_INTERVAL_SECONDS", 300),
+
+		// Idempotency
+		IdempotencyKeyTTLHours: getEnvAsInt("IDEMPOTENCY_KEY_TTL_HOURS", 24),
 	}
 }
 
@@ -60,6 +183,32 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvAsRouteTimeouts parses "METHOD /path=duration" pairs separated by commas,
+// e.g. "GET /api/admin/stats=30s,POST /api/orders=5s". Invalid entries are skipped.
+func getEnvAsRouteTimeouts(key, defaultValue string) map[string]time.Duration {
+	timeouts := make(map[string]time.Duration)
+	for _, entry := range strings.Split(getEnv(key, defaultValue), ",") {
+		route, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || timeout < 0 {
+			log.Printf("Ignoring invalid %s entry: %q", key, entry)
+			continue
+		}
+		timeouts[strings.Join(strings.Fields(route), " ")] = timeout
+	}
+	return timeouts
+}
+
 func (c *Config) GetDSN() string {
 	return "host=" + c.DBHost +
 		" port=" + c.DBPort +
@@ -73,10 +222,20 @@ func (c *Config) IsDevelopment() bool {
 	return c.Env == "development"
 }
 
-// LogConfig prints configuration (without sensitive data)
+// LogConfig logs configuration (without sensitive data)
 func (c *Config) LogConfig() {
-	log.Println("Configuration loaded:")
-	log.Printf("  Environment: %s", c.Env)
-	log.Printf("  Server Port: %s", c.Port)
-	log.Printf("  Database: %s@%s:%s/%s", c.DBUser, c.DBHost, c.DBPort, c.DBName)
+	slog.Info("Configuration loaded",
+		"environment", c.Env,
+		"port", c.Port,
+		"metrics_addr", c.MetricsAddr,
+		"log_level", c.LogLevel,
+		"log_format", c.LogFormat,
+		"request_timeout_seconds", c.RequestTimeoutSeconds,
+		"route_timeouts", len(c.RouteTimeouts),
+		"mail_driver", c.MailDriver,
+		"require_email_verification", c.RequireEmailVerification,
+		"auth_rate_limit_per_minute", c.AuthRateLimitPerMinute,
+		"trust_proxy_headers", c.TrustProxyHeaders,
+		"database", fmt.Sprintf("%s@%s:%s/%s", c.DBUser, c.DBHost, c.DBPort, c.DBName),
+	)
 }
@@ -1,28 +1,25 @@
 package db
 
 import (
-	"log"
+	"log/slog"
 	"mini-oms-backend/internal/config"
-	"mini-oms-backend/internal/models"
+	"mini-oms-backend/migrations"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 var DB *gorm.DB
 
-// Connect establishes database connection
+// Connect establishes database connection.
+// The schema is managed by versioned migrations (see Migrator), never altered on connect.
 func Connect(cfg *config.Config) error {
 	var err error
 
-	// Configure GORM logger
+	// Route GORM logs through slog; queries show up at LOG_LEVEL=debug
 	gormConfig := &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Silent),
-	}
-
-	if !cfg.IsDevelopment() {
-		gormConfig.Logger = logger.Default.LogMode(logger.Silent)
+		Logger: newSlogLogger(time.Duration(cfg.DBSlowQueryMs) * time.Millisecond),
 	}
 
 	// Connect to database
@@ -31,36 +28,26 @@ func Connect(cfg *config.Config) error {
 		return err
 	}
 
-	log.Println("Database connected successfully")
-
-	// Auto migrate models
-	if err := AutoMigrate(); err != nil {
-		return err
-	}
-
+	slog.Info("Database connected successfully")
 	return nil
 }
 
-// AutoMigrate runs GORM auto-migration for all models
-func AutoMigrate() error {
-	log.Println("Running auto-migration...")
-
-	err := DB.AutoMigrate(
-		&models.User{},
-		&models.Category{},
-		&models.Product{},
-		&models.Order{},
-		&models.OrderItem{},
-		&models.Payment{},
-		&models.AuditLog{},
-	)
-
+// EnsureMigrated refuses to continue if the schema is not at the version this build expects
+func EnsureMigrated() error {
+	migrator, err := NewMigrator()
 	if err != nil {
 		return err
 	}
+	return migrator.CheckUpToDate()
+}
 
-	log.Println("Auto-migration completed successfully")
-	return nil
+// LatestMigrationVersion is the schema version this build expects, read from the embedded migrations
+func LatestMigrationVersion() (int, error) {
+	list, err := loadMigrations(migrations.FS)
+	if err != nil {
+		return 0, err
+	}
+	return (&Migrator{migrations: list}).LatestVersion(), nil
 }
 
 // GetDB returns database instance
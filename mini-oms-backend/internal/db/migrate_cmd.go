@@ -0,0 +1,75 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+const migrateUsage = `usage: migrate <command>
+
+commands:
+  up             apply all pending migrations
+  down [steps]   roll back the last applied migration(s), default 1
+  to <version>   migrate up or down to the given version (0 rolls back everything)
+  status         list migrations and whether they are applied`
+
+// RunMigrateCommand executes the migrate subcommand shared by cmd/api and cmd/omsctl
+func RunMigrateCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := NewMigrator()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		return migrator.Up()
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return errors.New("steps must be a positive number")
+			}
+		}
+		return migrator.Down(steps)
+
+	case "to":
+		if len(args) < 2 {
+			return errors.New("missing target version")
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return errors.New("version must be a number")
+		}
+		return migrator.To(version)
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		current, err := migrator.CurrentVersion()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Current version: %d (latest: %d)\n", current, migrator.LatestVersion())
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("  %04d_%-35s %s\n", status.Version, status.Name, applied)
+		}
+		return nil
+
+	default:
+		return errors.New(migrateUsage)
+	}
+}
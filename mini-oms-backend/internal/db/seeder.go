@@ -1,10 +1,9 @@
 package db
 
 import (
-	"fmt"
 	"log"
-	"math/rand"
 	"mini-oms-backend/internal/models"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -13,7 +12,6 @@ import (
 func Seed(db *gorm.DB) {
 	seedUsers(db)
 	seedProducts(db)
-	fixOrderNumbers(db) // Fix data lama
 }
 
 func seedUsers(db *gorm.DB) {
@@ -24,11 +22,13 @@ func seedUsers(db *gorm.DB) {
 	}
 
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("admin123"), bcrypt.DefaultCost)
+	now := time.Now()
 	admin := models.User{
-		Name:     "Admin Tofuu",
-		Email:    "admin@whaleestore.com",
-		Password: string(hashedPassword),
-		Role:     "admin",
+		Name:            "Admin Tofuu",
+		Email:           "admin@whaleestore.com",
+		Password:        string(hashedPassword),
+		Role:            "admin",
+		EmailVerifiedAt: &now,
 	}
 	db.Create(&admin)
 	log.Println("Seeded Admin User: admin@whaleestore.com / admin123")
@@ -52,17 +52,3 @@ func seedProducts(db *gorm.DB) {
 	}
 	log.Println("Seeded Products")
 }
-
-func fixOrderNumbers(db *gorm.DB) {
-	var orders []models.Order
-	// Find orders with empty order number
-	if err := db.Where("order_number = ? OR order_number IS NULL", "").Find(&orders).Error; err != nil {
-		return
-	}
-
-	for _, order := range orders {
-		newNumber := fmt.Sprintf("ORD-FIX-%s-%d", order.CreatedAt.Format("20060102"), rand.Intn(10000))
-		db.Model(&order).Update("order_number", newNumber)
-		log.Printf("Fixed OrderNumber for %s: %s\n", order.ID, newNumber)
-	}
-}
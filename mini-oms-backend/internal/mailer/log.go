@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"mini-oms-backend/internal/utils"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LogMailer is meant for local development: it logs the recipient and subject of every
+// message instead of delivering it. The body, which may hold a password reset link, is
+// never logged; with a directory set each message is written there as an .eml file that
+// mail clients can open.
+type LogMailer struct {
+	from string
+	dir  string
+}
+
+func NewLogMailer(from, dir string) *LogMailer {
+	return &LogMailer{from: from, dir: dir}
+}
+
+// Send implements Mailer
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	data, err := format(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	note := "Email not delivered (log mailer): " + msg.Subject
+	if m.dir != "" {
+		if err := os.MkdirAll(m.dir, 0o700); err != nil {
+			return err
+		}
+		recipient := strings.NewReplacer("@", "_at_", "/", "_", string(filepath.Separator), "_").Replace(msg.To)
+		name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405.000000"), recipient)
+		path := filepath.Join(m.dir, name)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			return err
+		}
+		note += ", written to " + path
+	}
+
+	utils.LogInfo(ctx, "Mailer", msg.To, "Send", note)
+	return nil
+}
@@ -0,0 +1,28 @@
+package mailer
+
+import (
+	"mini-oms-backend/internal/config"
+	"testing"
+)
+
+func TestNewAllowsLogDriverOnlyInDevelopment(t *testing.T) {
+	tests := []struct {
+		env, driver string
+		wantErr     bool
+	}{
+		{"development", "log", false},
+		{"development", "", false},
+		{"production", "log", true},
+		{"production", "", true},
+		{"staging", "log", true},
+		{"production", "smtp", false},
+		{"development", "sendmail", true},
+	}
+
+	for _, tt := range tests {
+		_, err := New(&config.Config{Env: tt.env, MailDriver: tt.driver})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ENV=%s MAIL_DRIVER=%q: err = %v, want error %v", tt.env, tt.driver, err, tt.wantErr)
+		}
+	}
+}
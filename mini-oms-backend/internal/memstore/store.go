@@ -0,0 +1,195 @@
+// Package memstore is an in-memory data store backing the in-memory module repositories.
+// It is meant for tests and local experiments, not for production use.
+package memstore
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/utils"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Store holds every table in maps keyed by primary key. Rows are stored by value
+// without relations; repositories attach relations when reading.
+type Store struct {
+	mu   sync.Mutex // guards the maps
+	txMu sync.Mutex // serializes transactions
+
+	Roles           map[string]models.Role // with Permissions
+	Users           map[uuid.UUID]models.User
+	RefreshTokens   map[uuid.UUID]models.RefreshToken
+	UserTokens      map[uuid.UUID]models.UserToken
+	LoginFailures   map[uuid.UUID]models.LoginFailure
+	Invitations     map[uuid.UUID]models.Invitation
+	Categories      map[uuid.UUID]models.Category
+	Products        map[uuid.UUID]models.Product
+	Orders          map[uuid.UUID]models.Order
+	OrderItems      map[uuid.UUID]models.OrderItem
+	Payments        map[uuid.UUID]models.Payment
+	Refunds         map[uuid.UUID]models.Refund
+	IdempotencyKeys map[uuid.UUID]models.IdempotencyKey
+	AuditLogs       []models.AuditLog
+}
+
+// New returns an empty store. Like the database it always holds the system roles.
+func New() *Store {
+	return &Store{
+		Roles: map[string]models.Role{
+			models.RoleAdmin: {Name: models.RoleAdmin, Description: "Full access", IsSystem: true, Permissions: []string{"*"}},
+			models.RoleUser:  {Name: models.RoleUser, Description: "Customer account", IsSystem: true},
+		},
+		Users:           map[uuid.UUID]models.User{},
+		RefreshTokens:   map[uuid.UUID]models.RefreshToken{},
+		UserTokens:      map[uuid.UUID]models.UserToken{},
+		LoginFailures:   map[uuid.UUID]models.LoginFailure{},
+		Invitations:     map[uuid.UUID]models.Invitation{},
+		Categories:      map[uuid.UUID]models.Category{},
+		Products:        map[uuid.UUID]models.Product{},
+		Orders:          map[uuid.UUID]models.Order{},
+		OrderItems:      map[uuid.UUID]models.OrderItem{},
+		Payments:        map[uuid.UUID]models.Payment{},
+		Refunds:         map[uuid.UUID]models.Refund{},
+		IdempotencyKeys: map[uuid.UUID]models.IdempotencyKey{},
+	}
+}
+
+// Lock and Unlock guard direct access to the maps
+func (s *Store) Lock()   { s.mu.Lock() }
+func (s *Store) Unlock() { s.mu.Unlock() }
+
+// Transaction runs fn as a unit of work: if fn returns an error every change it made is rolled back.
+// Transactions are serialized, which also stands in for row locks. They must not be nested.
+// Like a database transaction, it is rolled back when ctx is done before fn returns.
+func (s *Store) Transaction(ctx context.Context, fn func() error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	snapshot := s.clone()
+	s.mu.Unlock()
+
+	err := fn()
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		s.mu.Lock()
+		s.restore(snapshot)
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// LogAudit records an audit log entry, mirroring utils.LogAudit
+func (s *Store) LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.AuditLogs = append(s.AuditLogs, models.AuditLog{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Action:     action,
+		EntityName: entityName,
+		EntityID:   entityID,
+		Details:    details,
+		RequestID:  utils.RequestIDFromContext(ctx),
+		CreatedAt:  time.Now(),
+	})
+	return nil
+}
+
+// ErrDuplicateEmail mirrors the unique index on users.email
+var ErrDuplicateEmail = errors.New("duplicate key value violates unique constraint \"idx_users_email\"")
+
+// InsertUser stores a new user, enforcing unique emails among undeleted users like the database does
+func (s *Store) InsertUser(user *models.User) error {
+	if err := user.BeforeCreate(nil); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.Users {
+		if existing.Email == user.Email && !existing.DeletedAt.Valid {
+			return ErrDuplicateEmail
+		}
+	}
+
+	now := time.Now()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	s.Users[user.ID] = *user
+	return nil
+}
+
+// FindUserByEmail finds a user that has not been deleted (store must be locked)
+func (s *Store) FindUserByEmail(email string) (models.User, bool) {
+	for _, user := range s.Users {
+		if user.Email == email && !user.DeletedAt.Valid {
+			return user, true
+		}
+	}
+	return models.User{}, false
+}
+
+// AuditActions lists the recorded audit actions for an entity, oldest first
+func (s *Store) AuditActions(entityID uuid.UUID) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var actions []string
+	for _, log := range s.AuditLogs {
+		if log.EntityID == entityID {
+			actions = append(actions, log.Action)
+		}
+	}
+	return actions
+}
+
+func (s *Store) clone() *Store {
+	return &Store{
+		Roles:           maps.Clone(s.Roles),
+		Users:           maps.Clone(s.Users),
+		RefreshTokens:   maps.Clone(s.RefreshTokens),
+		UserTokens:      maps.Clone(s.UserTokens),
+		LoginFailures:   maps.Clone(s.LoginFailures),
+		Invitations:     maps.Clone(s.Invitations),
+		Categories:      maps.Clone(s.Categories),
+		Products:        maps.Clone(s.Products),
+		Orders:          maps.Clone(s.Orders),
+		OrderItems:      maps.Clone(s.OrderItems),
+		Payments:        maps.Clone(s.Payments),
+		Refunds:         maps.Clone(s.Refunds),
+		IdempotencyKeys: maps.Clone(s.IdempotencyKeys),
+		AuditLogs:       slices.Clone(s.AuditLogs),
+	}
+}
+
+func (s *Store) restore(snapshot *Store) {
+	s.Roles = snapshot.Roles
+	s.Users = snapshot.Users
+	s.RefreshTokens = snapshot.RefreshTokens
+	s.UserTokens = snapshot.UserTokens
+	s.LoginFailures = snapshot.LoginFailures
+	s.Invitations = snapshot.Invitations
+	s.Categories = snapshot.Categories
+	s.Products = snapshot.Products
+	s.Orders = snapshot.Orders
+	s.OrderItems = snapshot.OrderItems
+	s.Payments = snapshot.Payments
+	s.Refunds = snapshot.Refunds
+	s.IdempotencyKeys = snapshot.IdempotencyKeys
+	s.AuditLogs = snapshot.AuditLogs
+}
@@ -0,0 +1,82 @@
+// Package metrics holds the Prometheus collectors exposed on /metrics.
+//
+// Business counters are incremented by the services after their transaction has
+// committed, so rolled back work is never counted.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "mini_oms"
+
+// Registry is the registry served on /metrics; it also carries Go runtime and process metrics
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	// HTTPRequestDuration observes request latency by route pattern and response status
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	OrdersCreated = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_created_total",
+		Help:      "Orders created.",
+	})
+
+	// OrdersCanceled is labeled by who canceled: user, admin or system (payment expiry)
+	OrdersCanceled = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_canceled_total",
+		Help:      "Orders canceled by actor role.",
+	}, []string{"actor"})
+
+	// OutOfStockRejections is labeled by the operation that could not reserve stock: create or reopen
+	OutOfStockRejections = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "out_of_stock_rejections_total",
+		Help:      "Order operations rejected because of insufficient stock.",
+	}, []string{"operation"})
+
+	PaymentsVerified = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payments_verified_total",
+		Help:      "Payments verified by an admin.",
+	})
+
+	PaymentsRejected = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payments_rejected_total",
+		Help:      "Payments rejected by an admin.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// RegisterDBStats exposes the connection pool statistics of db (open, in use, idle, waits)
+func RegisterDBStats(db *sql.DB) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, "mini_oms"))
+}
+
+// Handler serves the registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,132 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/utils"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// IdempotencyStore claims Idempotency-Key requests and keeps their responses,
+// see idempotency.Service
+type IdempotencyStore interface {
+	Begin(ctx context.Context, userID uuid.UUID, route, key, requestHash string) (*models.IdempotencyKey, error)
+	Complete(ctx context.Context, id uuid.UUID, statusCode int, body []byte) error
+	Release(ctx context.Context, id uuid.UUID) error
+}
+
+// IdempotencyMiddleware makes a route safe to retry: a request repeated with the same
+// Idempotency-Key header gets the stored response instead of running again. Reusing a
+// key for a different request, or while the first one is still running, is a 409.
+// Server errors are not stored so they can be retried. Must run after JWTMiddleware,
+// since keys are scoped per user.
+func IdempotencyMiddleware(store IdempotencyStore) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(HeaderIdempotencyKey)
+			if key == "" {
+				return next(c)
+			}
+			if !isValidIdempotencyKey(key) {
+				return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid Idempotency-Key header")
+			}
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := c.Request().Context()
+			userID := c.Get("user_id").(uuid.UUID)
+			route := c.Request().Method + " " + c.Path()
+
+			record, err := store.Begin(ctx, userID, route, key, requestFingerprint(c.Request().URL.RequestURI(), body))
+			if err != nil {
+				return err
+			}
+			if record.IsCompleted() {
+				c.Response().Header().Set(HeaderIdempotentReplayed, "true")
+				return c.Blob(record.StatusCode, echo.MIMEApplicationJSON, []byte(record.ResponseBody))
+			}
+
+			recorder := &bodyRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			err = next(c)
+			// Render a returned error now so the response sent to the client is the one stored
+			if err != nil && !c.Response().Committed {
+				c.Error(err)
+			}
+
+			// Record the outcome even if the request was canceled meanwhile
+			saveCtx := context.WithoutCancel(ctx)
+			status := c.Response().Status
+			if !c.Response().Committed || status >= http.StatusInternalServerError {
+				if releaseErr := store.Release(saveCtx, record.ID); releaseErr != nil {
+					utils.LogError(ctx, "Idempotency", key, "Release", releaseErr, "Failed to release idempotency key")
+				}
+			} else if saveErr := store.Complete(saveCtx, record.ID, status, recorder.body.Bytes()); saveErr != nil {
+				utils.LogError(ctx, "Idempotency", key, "Complete", saveErr, "Failed to store idempotent response")
+			}
+			return err
+		}
+	}
+}
+
+func isValidIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// requestFingerprint hashes the URI and body. JSON bodies are compacted with sorted keys
+// first, so formatting differences do not count as a different request.
+func requestFingerprint(uri string, body []byte) string {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err == nil && !decoder.More() {
+		if canonical, err := json.Marshal(value); err == nil {
+			body = canonical
+		}
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(uri))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// bodyRecorder copies the response body while it is written to the client
+type bodyRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,116 @@
+package middlewares
+
+import (
+	"errors"
+	"mini-oms-backend/internal/apperror"
+	"mini-oms-backend/internal/memstore"
+	"mini-oms-backend/internal/modules/idempotency"
+	"mini-oms-backend/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// newIdempotentServer serves POST /orders behind IdempotencyMiddleware for one user.
+// handle gets the number of times the handler ran, including this one.
+func newIdempotentServer(handle func(c echo.Context, calls int) error) *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = utils.HTTPErrorHandler
+
+	store := idempotency.NewService(idempotency.NewMemoryRepository(memstore.New()), time.Hour)
+	userID := uuid.New()
+	authenticate := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user_id", userID)
+			return next(c)
+		}
+	}
+
+	calls := 0
+	e.POST("/orders", func(c echo.Context) error {
+		calls++
+		return handle(c, calls)
+	}, authenticate, IdempotencyMiddleware(store))
+	return e
+}
+
+func post(e *echo.Echo, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(HeaderIdempotencyKey, key)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyMiddlewareReplaysStoredResponse(t *testing.T) {
+	e := newIdempotentServer(func(c echo.Context, calls int) error {
+		return utils.SuccessResponse(c, http.StatusCreated, "Order created", map[string]int{"call": calls})
+	})
+
+	first := post(e, "key-1", `{"product_id":"a","quantity":1}`)
+	if first.Code != http.StatusCreated || first.Header().Get(HeaderIdempotentReplayed) != "" {
+		t.Fatalf("first: %d replayed=%q, want fresh 201", first.Code, first.Header().Get(HeaderIdempotentReplayed))
+	}
+
+	// Same request with different JSON formatting
+	replay := post(e, "key-1", `{ "quantity": 1, "product_id": "a" }`)
+	if replay.Code != http.StatusCreated || replay.Header().Get(HeaderIdempotentReplayed) != "true" {
+		t.Fatalf("replay: %d replayed=%q, want replayed 201", replay.Code, replay.Header().Get(HeaderIdempotentReplayed))
+	}
+	if replay.Body.String() != first.Body.String() {
+		t.Errorf("replay body = %s, want %s", replay.Body, first.Body)
+	}
+}
+
+func TestIdempotencyMiddlewareRejectsKeyReusedForDifferentBody(t *testing.T) {
+	e := newIdempotentServer(func(c echo.Context, calls int) error {
+		return utils.SuccessResponse(c, http.StatusCreated, "Order created", nil)
+	})
+
+	post(e, "key-1", `{"quantity":1}`)
+	rec := post(e, "key-1", `{"quantity":2}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409", rec.Code)
+	}
+}
+
+func TestIdempotencyMiddlewareReleasesKeyAfterServerError(t *testing.T) {
+	e := newIdempotentServer(func(c echo.Context, calls int) error {
+		if calls == 1 {
+			return errors.New("pq: connection reset")
+		}
+		return utils.SuccessResponse(c, http.StatusCreated, "Order created", nil)
+	})
+
+	if rec := post(e, "key-1", `{}`); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("first: status = %d, want 500", rec.Code)
+	}
+	rec := post(e, "key-1", `{}`)
+	if rec.Code != http.StatusCreated || rec.Header().Get(HeaderIdempotentReplayed) != "" {
+		t.Fatalf("retry: %d replayed=%q, want fresh 201", rec.Code, rec.Header().Get(HeaderIdempotentReplayed))
+	}
+}
+
+func TestIdempotencyMiddlewareStoresRenderedDomainError(t *testing.T) {
+	e := newIdempotentServer(func(c echo.Context, calls int) error {
+		return apperror.InsufficientStock("insufficient stock for product: call " + strconv.Itoa(calls))
+	})
+
+	first := post(e, "key-1", `{}`)
+	if first.Code != http.StatusConflict || !strings.Contains(first.Body.String(), apperror.CodeInsufficientStock) {
+		t.Fatalf("first: %d %s, want 409 %s", first.Code, first.Body, apperror.CodeInsufficientStock)
+	}
+
+	// The handler does not run again: the stored 409 is replayed as is
+	replay := post(e, "key-1", `{}`)
+	if replay.Code != http.StatusConflict || replay.Header().Get(HeaderIdempotentReplayed) != "true" || replay.Body.String() != first.Body.String() {
+		t.Fatalf("replay: replayed=%q body=%s, want stored %s", replay.Header().Get(HeaderIdempotentReplayed), replay.Body, first.Body)
+	}
+}
@@ -1,16 +1,23 @@
 package middlewares
 
 import (
+	"context"
 	"mini-oms-backend/internal/config"
 	"mini-oms-backend/internal/utils"
 	"net/http"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
-// JWTMiddleware validates JWT token
-func JWTMiddleware(cfg *config.Config) echo.MiddlewareFunc {
+// SessionChecker reports whether the session an access token belongs to is still active
+type SessionChecker interface {
+	IsSessionActive(ctx context.Context, sessionID uuid.UUID) bool
+}
+
+// JWTMiddleware validates JWT token and rejects tokens whose session has been revoked
+func JWTMiddleware(cfg *config.Config, sessions SessionChecker) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			// Extract token from Authorization header
@@ -33,10 +40,17 @@ func JWTMiddleware(cfg *config.Config) echo.MiddlewareFunc {
 				return utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired token")
 			}
 
+			// Check revocation (logout, refresh token reuse)
+			if !sessions.IsSessionActive(c.Request().Context(), claims.SessionID) {
+				return utils.ErrorResponse(c, http.StatusUnauthorized, "Token has been revoked")
+			}
+
 			// Set user context
 			c.Set("user_id", claims.UserID)
 			c.Set("user_email", claims.Email)
 			c.Set("user_role", claims.Role)
+			c.Set("user_permissions", claims.Permissions)
+			c.Set("session_id", claims.SessionID)
 
 			return next(c)
 		}
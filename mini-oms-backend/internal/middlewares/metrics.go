@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"mini-oms-backend/internal/metrics"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MetricsMiddleware records request latency per route pattern (e.g. /api/orders/:id),
+// so path parameters do not create a time series per ID
+func MetricsMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+
+			// Render returned errors now so the recorded status is the one sent to the client;
+			// the error handler skips responses that are already committed
+			if err != nil && !c.Response().Committed {
+				c.Error(err)
+			}
+			status := c.Response().Status
+
+			// Requests that matched no route share one series
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+
+			metrics.HTTPRequestDuration.
+				WithLabelValues(c.Request().Method, route, strconv.Itoa(status)).
+				Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
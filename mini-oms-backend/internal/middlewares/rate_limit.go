@@ -0,0 +1,95 @@
+package middlewares
+
+import (
+	"mini-oms-backend/internal/apperror"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/time/rate"
+)
+
+var errRateLimited = apperror.TooManyRequests("too many requests, slow down")
+
+// RateLimitMiddleware limits each client IP (echo.Context.RealIP) to requestsPerMinute,
+// with bursts of up to burst requests. Rejected requests get a 429 with Retry-After.
+// Routes sharing one middleware share the limit; apply it to a group or to single routes:
+//
+//	limit := RateLimitMiddleware(30, 10)
+//	auth := api.Group("/auth", limit)
+//	api.POST("/invitations/accept", handler, limit)
+//
+// Limits are kept in memory, so each instance enforces its own. A non-positive
+// requestsPerMinute disables the limit.
+func RateLimitMiddleware(requestsPerMinute, burst int) echo.MiddlewareFunc {
+	if requestsPerMinute <= 0 {
+		return func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+	}
+
+	limiter := &rateLimiter{
+		limit:   rate.Limit(float64(requestsPerMinute) / 60),
+		burst:   max(burst, 1),
+		clients: map[string]*rateClient{},
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if wait := limiter.reserve(c.RealIP(), time.Now()); wait > 0 {
+				return apperror.RetryAfter(errRateLimited, wait)
+			}
+			return next(c)
+		}
+	}
+}
+
+// rateLimiter keeps a token bucket per client
+type rateLimiter struct {
+	mu        sync.Mutex
+	limit     rate.Limit
+	burst     int
+	clients   map[string]*rateClient
+	lastSweep time.Time
+}
+
+type rateClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// reserve takes a token for key and returns 0, or how long to wait if none is left
+func (l *rateLimiter) reserve(key string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	client, ok := l.clients[key]
+	if !ok {
+		client = &rateClient{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[key] = client
+	}
+	client.lastSeen = now
+
+	reservation := client.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		// Rejected requests do not consume a token
+		reservation.CancelAt(now)
+		return delay
+	}
+	return 0
+}
+
+// sweep forgets, at most once a minute, clients idle long enough for their bucket to be full again
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	refill := time.Duration(float64(l.burst) / float64(l.limit) * float64(time.Second))
+	for key, client := range l.clients {
+		if now.Sub(client.lastSeen) > refill {
+			delete(l.clients, key)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package middlewares
+
+import (
+	"mini-oms-backend/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/time/rate"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	// One token per second, bursts of two
+	limiter := &rateLimiter{limit: rate.Limit(1), burst: 2, clients: map[string]*rateClient{}}
+	start := time.Now()
+
+	steps := []struct {
+		name string
+		key  string
+		at   time.Duration
+		wait time.Duration
+	}{
+		{"burst 1", "a", 0, 0},
+		{"burst 2", "a", 0, 0},
+		{"bucket empty", "a", 0, time.Second},
+		{"rejection consumed no token", "a", 0, time.Second},
+		{"refilled", "a", time.Second, 0},
+		{"other client has its own bucket", "b", time.Second, 0},
+		{"empty again", "a", 1500 * time.Millisecond, 500 * time.Millisecond},
+	}
+	for _, step := range steps {
+		if wait := limiter.reserve(step.key, start.Add(step.at)); wait != step.wait {
+			t.Fatalf("%s: wait = %v, want %v", step.name, wait, step.wait)
+		}
+	}
+
+	// Clients idle longer than a full refill are forgotten on the next sweep
+	limiter.reserve("c", start.Add(2*time.Minute))
+	if len(limiter.clients) != 1 || limiter.clients["c"] == nil {
+		t.Fatalf("clients after sweep = %d, want only c", len(limiter.clients))
+	}
+}
+
+func TestRateLimitMiddlewareRejectsWithRetryAfter(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = utils.HTTPErrorHandler
+	e.POST("/login", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}, RateLimitMiddleware(1, 1))
+
+	send := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/login", nil))
+		return rec
+	}
+
+	if rec := send(); rec.Code != http.StatusNoContent {
+		t.Fatalf("first: status = %d, want 204", rec.Code)
+	}
+	rec := send()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second: status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q, want 60", got)
+	}
+}
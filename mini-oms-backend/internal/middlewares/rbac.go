@@ -1,24 +1,24 @@
 package middlewares
 
 import (
+	"mini-oms-backend/internal/policy"
 	"mini-oms-backend/internal/utils"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 )
 
-// AdminOnlyMiddleware checks if user has admin role
-func AdminOnlyMiddleware() echo.MiddlewareFunc {
+// RequirePermission allows only users whose role grants permission, e.g.
+// RequirePermission(policy.PermPaymentsVerify). Must run after JWTMiddleware.
+func RequirePermission(permission string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			userRole := c.Get("user_role")
-			if userRole == nil {
+			if c.Get("user_id") == nil {
 				return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
 			}
 
-			role, ok := userRole.(string)
-			if !ok || role != "admin" {
-				return utils.ErrorResponse(c, http.StatusForbidden, "Access forbidden: admin only")
+			if !policy.ActorFromContext(c).Can(permission) {
+				return utils.ErrorResponse(c, http.StatusForbidden, "Access forbidden: requires "+permission+" permission")
 			}
 
 			return next(c)
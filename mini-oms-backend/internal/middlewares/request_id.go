@@ -0,0 +1,42 @@
+package middlewares
+
+import (
+	"mini-oms-backend/internal/utils"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// maxRequestIDLength bounds client-supplied IDs so they fit the audit_logs column
+const maxRequestIDLength = 64
+
+// RequestIDMiddleware assigns every request an ID, reusing a sane X-Request-ID sent by
+// the client or proxy. The ID is echoed in the response header and stored in the
+// request context, where loggers and audit records pick it up.
+func RequestIDMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			requestID := c.Request().Header.Get(echo.HeaderXRequestID)
+			if !validRequestID(requestID) {
+				requestID = uuid.NewString()
+			}
+
+			c.Response().Header().Set(echo.HeaderXRequestID, requestID)
+			c.SetRequest(c.Request().WithContext(utils.WithRequestID(c.Request().Context(), requestID)))
+
+			return next(c)
+		}
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
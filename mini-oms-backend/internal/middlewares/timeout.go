@@ -0,0 +1,40 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"mini-oms-backend/internal/utils"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TimeoutMiddleware puts a deadline on the request context. Routes are matched by
+// "METHOD /route/pattern" (e.g. "GET /api/orders/:id"); routes without an override
+// use defaultTimeout, and a zero timeout leaves the request unbounded.
+func TimeoutMiddleware(defaultTimeout time.Duration, routeTimeouts map[string]time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			timeout, ok := routeTimeouts[c.Request().Method+" "+c.Path()]
+			if !ok {
+				timeout = defaultTimeout
+			}
+			if timeout <= 0 {
+				return next(c)
+			}
+
+			ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+			defer cancel()
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			err := next(c)
+
+			// Handlers that gave up without writing a response still owe the client a 504
+			if !c.Response().Committed && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return utils.ErrorResponse(c, http.StatusGatewayTimeout, "Request timed out")
+			}
+			return err
+		}
+	}
+}
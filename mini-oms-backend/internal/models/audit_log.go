@@ -7,13 +7,17 @@ import (
 	"gorm.io/gorm"
 )
 
+// SystemActorID is recorded as AuditLog.UserID for actions taken by background jobs
+var SystemActorID = uuid.Nil
+
 type AuditLog struct {
 	ID         uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
-	UserID     uuid.UUID `gorm:"type:uuid;index" json:"user_id"`        // Siapa yang melakukan
-	Action     string    `gorm:"type:varchar(100);index" json:"action"` // Apa yang dilakukan (e.g., "ORDER_CREATED")
-	EntityName string    `gorm:"type:varchar(100)" json:"entity_name"`  // Object apa (e.g., "Order")
-	EntityID   uuid.UUID `gorm:"type:uuid;index" json:"entity_id"`      // ID object tersebut
-	Details    string    `gorm:"type:text" json:"details"`              // Tambahan info (opsional, bisa JSON)
+	UserID     uuid.UUID `gorm:"type:uuid;index" json:"user_id"`           // Siapa yang melakukan
+	Action     string    `gorm:"type:varchar(100);index" json:"action"`    // Apa yang dilakukan (e.g., "ORDER_CREATED")
+	EntityName string    `gorm:"type:varchar(100)" json:"entity_name"`     // Object apa (e.g., "Order")
+	EntityID   uuid.UUID `gorm:"type:uuid;index" json:"entity_id"`         // ID object tersebut
+	Details    string    `gorm:"type:text" json:"details"`                 // Tambahan info (opsional, bisa JSON)
+	RequestID  string    `gorm:"type:varchar(64);index" json:"request_id"` // Request yang memicu (kosong untuk CLI/worker)
 	CreatedAt  time.Time `json:"created_at"`
 }
 
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IdempotencyKey remembers the response to a request sent with an Idempotency-Key header,
+// so a retry of the same request gets the same response instead of running twice.
+// Keys are scoped per user and route.
+type IdempotencyKey struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_idempotency_keys_scope" json:"user_id"`
+	Route        string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_keys_scope" json:"route"` // "METHOD /route/pattern"
+	Key          string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_keys_scope" json:"key"`
+	RequestHash  string    `gorm:"type:varchar(64);not null" json:"-"`    // SHA-256 fingerprint of the request
+	StatusCode   int       `gorm:"not null;default:0" json:"status_code"` // 0 while the first request is still running
+	ResponseBody string    `gorm:"type:text" json:"-"`
+	ExpiresAt    time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (k *IdempotencyKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsCompleted checks if the response has been stored and can be replayed
+func (k *IdempotencyKey) IsCompleted() bool {
+	return k.StatusCode != 0
+}
+
+// IsExpired checks if the key is past its retention window
+func (k *IdempotencyKey) IsExpired() bool {
+	return !time.Now().Before(k.ExpiresAt)
+}
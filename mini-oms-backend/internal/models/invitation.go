@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Invitation lets an admin onboard another admin with a single-use token
+type Invitation struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;" json:"id"`
+	Email      string     `gorm:"type:varchar(255);not null;index" json:"email"`
+	Role       string     `gorm:"type:varchar(20);not null;default:'admin'" json:"role"`
+	TokenHash  string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"` // SHA-256 of the plain token
+	InvitedBy  uuid.UUID  `gorm:"type:uuid;not null" json:"invited_by"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Relations
+	Inviter *User `gorm:"foreignKey:InvitedBy" json:"inviter,omitempty"`
+}
+
+func (i *Invitation) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	if i.Role == "" {
+		i.Role = "admin"
+	}
+	return nil
+}
+
+// IsUsable checks if invitation can still be accepted
+func (i *Invitation) IsUsable() bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && time.Now().Before(i.ExpiresAt)
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LoginFailure records a failed login, used to throttle password guessing per account
+// and per client IP. ClearedAt is set once the account logs in or is unlocked, so the
+// failure stops counting against the account but still counts against the IP.
+type LoginFailure struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;" json:"id"`
+	Email     string     `gorm:"type:varchar(255);not null;index" json:"email"`
+	IPAddress string     `gorm:"type:varchar(45);not null;index" json:"ip_address"`
+	ClearedAt *time.Time `json:"cleared_at"`
+	CreatedAt time.Time  `gorm:"index" json:"created_at"`
+}
+
+func (f *LoginFailure) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
@@ -9,26 +9,33 @@ import (
 )
 
 type Order struct {
-	ID          uuid.UUID      `gorm:"type:uuid;primary_key;" json:"id"`
-	UserID      uuid.UUID      `gorm:"type:uuid;index" json:"user_id"`
-	OrderNumber string         `gorm:"type:varchar(50);uniqueIndex" json:"order_number"` // New field
-	TotalAmount float64        `gorm:"type:decimal(12,2);not null" json:"total_amount"`
-	Status      string         `gorm:"type:varchar(20);default:'created'" json:"status"` // created, processing, completed, canceled
-	Notes       string         `gorm:"type:text" json:"notes"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID             uuid.UUID      `gorm:"type:uuid;primary_key;" json:"id"`
+	UserID         uuid.UUID      `gorm:"type:uuid;index" json:"user_id"`
+	OrderNumber    string         `gorm:"type:varchar(50);uniqueIndex" json:"order_number"` // New field
+	TotalAmount    float64        `gorm:"type:decimal(12,2);not null" json:"total_amount"`
+	Status         string         `gorm:"type:varchar(20);default:'created'" json:"status"` // created, processing, shipped, completed, canceled
+	Notes          string         `gorm:"type:text" json:"notes"`
+	TrackingNumber string         `gorm:"type:varchar(100)" json:"tracking_number,omitempty"`
+	ShippedAt      *time.Time     `json:"shipped_at,omitempty"`
+	CompletedAt    *time.Time     `json:"completed_at,omitempty"`
+	CanceledAt     *time.Time     `json:"canceled_at,omitempty"`
+	ReopenedAt     *time.Time     `json:"reopened_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relations
 	User       *User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	OrderItems []OrderItem `gorm:"foreignKey:OrderID" json:"items,omitempty"`
-	Payment    *Payment    `gorm:"foreignKey:OrderID" json:"payment,omitempty"`
+	Payment    *Payment    `gorm:"foreignKey:OrderID" json:"payment,omitempty"`  // Latest payment attempt
+	Payments   []Payment   `gorm:"foreignKey:OrderID" json:"payments,omitempty"` // All payment attempts, incl. rejected
 }
 
 // Order Status Constants
 const (
 	OrderStatusCreated    = "created"
 	OrderStatusProcessing = "processing" // Paid but not yet shipped
+	OrderStatusShipped    = "shipped"
 	OrderStatusCompleted  = "completed"
 	OrderStatusCanceled   = "canceled"
 )
@@ -41,7 +48,7 @@ func (o *Order) BeforeCreate(tx *gorm.DB) error {
 		o.OrderNumber = generateOrderNumber()
 	}
 	if o.Status == "" {
-		o.Status = "created"
+		o.Status = OrderStatusCreated
 	}
 	return nil
 }
@@ -51,13 +58,7 @@ func generateOrderNumber() string {
 	return fmt.Sprintf("ORD-%s-%d", time.Now().Format("20060102"), time.Now().Unix()%10000)
 }
 
-// CanBeCanceled checks if order can be canceled
-func (o *Order) CanBeCanceled() bool {
-	// Only 'created' (Unpaid) orders can be canceled by user/admin
-	return o.Status == "created"
-}
-
-// CanBeCompleted checks if order can be marked as completed
-func (o *Order) CanBeCompleted() bool {
-	return o.Status == "processing"
+// IsFinal checks if order reached a terminal status
+func (o *Order) IsFinal() bool {
+	return o.Status == OrderStatusCompleted || o.Status == OrderStatusCanceled
 }
@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"mini-oms-backend/internal/apperror"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,24 +10,82 @@ import (
 )
 
 type Payment struct {
-	ID              uuid.UUID  `gorm:"type:uuid;primary_key;" json:"id"`
-	OrderID         uuid.UUID  `gorm:"type:uuid;uniqueIndex;not null" json:"order_id"` // One payment per order
-	PaymentNumber   string     `gorm:"type:varchar(50);uniqueIndex;not null" json:"payment_number"`
-	Amount          float64    `gorm:"type:decimal(12,2);not null" json:"amount"`
-	PaymentMethod   string     `gorm:"type:varchar(50);not null" json:"payment_method"`           // bank_transfer, e-wallet, credit_card
-	Status          string     `gorm:"type:varchar(20);not null;default:'pending'" json:"status"` // pending, paid, failed
-	PaymentProofURL string     `gorm:"type:varchar(500)" json:"payment_proof_url"`
-	VerifiedBy      *uuid.UUID `gorm:"type:uuid" json:"verified_by"`
-	VerifiedAt      *time.Time `json:"verified_at"`
-	Notes           string     `gorm:"type:text" json:"notes"`
-	CreatedAt       time.Time  `json:"created_at"`
-	UpdatedAt       time.Time  `json:"updated_at"`
+	ID              uuid.UUID     `gorm:"type:uuid;primary_key;" json:"id"`
+	OrderID         uuid.UUID     `gorm:"type:uuid;index:idx_payments_order;not null" json:"order_id"` // One active (non-failed) payment per order, see migrations/0007
+	PaymentNumber   string        `gorm:"type:varchar(50);uniqueIndex;not null" json:"payment_number"`
+	Amount          float64       `gorm:"type:decimal(12,2);not null" json:"amount"`
+	PaymentMethod   string        `gorm:"type:varchar(50);not null" json:"payment_method"`           // bank_transfer, e-wallet, credit_card
+	Status          PaymentStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"` // pending, paid, failed
+	PaymentProofURL string        `gorm:"type:varchar(500)" json:"payment_proof_url"`
+	VerifiedBy      *uuid.UUID    `gorm:"type:uuid" json:"verified_by"`
+	VerifiedAt      *time.Time    `json:"verified_at"`
+	Notes           string        `gorm:"type:text" json:"notes"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
 
 	// Relations
 	Order    *Order `gorm:"foreignKey:OrderID" json:"order,omitempty"`
 	Verifier *User  `gorm:"foreignKey:VerifiedBy" json:"verifier,omitempty"`
 }
 
+// PaymentStatus is the single vocabulary for payment states
+type PaymentStatus string
+
+// Payment Status Constants
+const (
+	PaymentStatusPending PaymentStatus = "pending"
+	PaymentStatusPaid    PaymentStatus = "paid"
+	PaymentStatusFailed  PaymentStatus = "failed"
+
+	PaymentStatusPartiallyRefunded PaymentStatus = "partially_refunded"
+	PaymentStatusRefunded          PaymentStatus = "refunded"
+)
+
+// paymentTransitions is the payment state machine: from -> allowed next statuses
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentStatusPending:           {PaymentStatusPaid, PaymentStatusFailed},
+	PaymentStatusPaid:              {PaymentStatusPartiallyRefunded, PaymentStatusRefunded},
+	PaymentStatusPartiallyRefunded: {PaymentStatusPartiallyRefunded, PaymentStatusRefunded},
+}
+
+// IsRefundable checks if payment has captured money that can be refunded
+func (p *Payment) IsRefundable() bool {
+	return p.Status == PaymentStatusPaid || p.Status == PaymentStatusPartiallyRefunded
+}
+
+// CanTransitionTo checks if a payment in this status may move to next
+func (s PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	for _, allowed := range paymentTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// PaymentTransitionError is returned when a payment status change is not allowed
+type PaymentTransitionError struct {
+	From PaymentStatus
+	To   PaymentStatus
+}
+
+func (e *PaymentTransitionError) Error() string {
+	return fmt.Sprintf("cannot change payment status from %s to %s", e.From, e.To)
+}
+
+// Is makes errors.Is(err, apperror.ErrInvalidTransition) match, so it is answered with 409
+func (e *PaymentTransitionError) Is(target error) bool {
+	return target == apperror.ErrInvalidTransition
+}
+
+// ErrorDetails implements apperror.Detailer
+func (e *PaymentTransitionError) ErrorDetails() map[string]interface{} {
+	return map[string]interface{}{
+		"from": e.From,
+		"to":   e.To,
+	}
+}
+
 func (p *Payment) BeforeCreate(tx *gorm.DB) error {
 	if p.ID == uuid.Nil {
 		p.ID = uuid.New()
@@ -35,7 +94,7 @@ func (p *Payment) BeforeCreate(tx *gorm.DB) error {
 		p.PaymentNumber = generatePaymentNumber()
 	}
 	if p.Status == "" {
-		p.Status = "pending"
+		p.Status = PaymentStatusPending
 	}
 	return nil
 }
@@ -47,22 +106,45 @@ func generatePaymentNumber() string {
 
 // IsPending checks if payment is still pending
 func (p *Payment) IsPending() bool {
-	return p.Status == "pending"
+	return p.Status == PaymentStatusPending
+}
+
+// transitionTo validates and applies a status change
+func (p *Payment) transitionTo(next PaymentStatus) error {
+	if !p.Status.CanTransitionTo(next) {
+		return &PaymentTransitionError{From: p.Status, To: next}
+	}
+	p.Status = next
+	return nil
 }
 
 // MarkAsPaid marks payment as paid
-func (p *Payment) MarkAsPaid(adminID uuid.UUID) {
-	p.Status = "paid"
+func (p *Payment) MarkAsPaid(adminID uuid.UUID) error {
+	if err := p.transitionTo(PaymentStatusPaid); err != nil {
+		return err
+	}
 	p.VerifiedBy = &adminID
 	now := time.Now()
 	p.VerifiedAt = &now
+	return nil
 }
 
 // MarkAsFailed marks payment as failed
-func (p *Payment) MarkAsFailed(adminID uuid.UUID, reason string) {
-	p.Status = "failed"
+func (p *Payment) MarkAsFailed(adminID uuid.UUID, reason string) error {
+	if err := p.transitionTo(PaymentStatusFailed); err != nil {
+		return err
+	}
 	p.VerifiedBy = &adminID
 	now := time.Now()
 	p.VerifiedAt = &now
 	p.Notes = reason
+	return nil
+}
+
+// MarkAsRefunded records a completed refund; fully is true once the whole amount was returned
+func (p *Payment) MarkAsRefunded(fully bool) error {
+	if fully {
+		return p.transitionTo(PaymentStatusRefunded)
+	}
+	return p.transitionTo(PaymentStatusPartiallyRefunded)
 }
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"mini-oms-backend/internal/apperror"
 	"time"
 
 	"github.com/google/uuid"
@@ -38,7 +39,7 @@ func (p *Product) IsInStock(quantity int) bool {
 // ReduceStock reduces product stock
 func (p *Product) ReduceStock(quantity int) error {
 	if !p.IsInStock(quantity) {
-		return gorm.ErrRecordNotFound // or custom error
+		return apperror.InsufficientStock("insufficient stock for product " + p.Name)
 	}
 	p.Stock -= quantity
 	return nil
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"fmt"
+	"mini-oms-backend/internal/apperror"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefundStatus tracks a refund from request until the money is returned
+type RefundStatus string
+
+// Refund Status Constants
+const (
+	RefundStatusPending   RefundStatus = "pending"
+	RefundStatusCompleted RefundStatus = "completed"
+	RefundStatusFailed    RefundStatus = "failed"
+)
+
+type Refund struct {
+	ID           uuid.UUID    `gorm:"type:uuid;primary_key;" json:"id"`
+	PaymentID    uuid.UUID    `gorm:"type:uuid;not null;index" json:"payment_id"`
+	OrderID      uuid.UUID    `gorm:"type:uuid;not null;index" json:"order_id"`
+	RefundNumber string       `gorm:"type:varchar(50);uniqueIndex;not null" json:"refund_number"`
+	Amount       float64      `gorm:"type:decimal(12,2);not null" json:"amount"`
+	Reason       string       `gorm:"type:text" json:"reason"`
+	Status       RefundStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"` // pending, completed, failed
+	RequestedBy  uuid.UUID    `gorm:"type:uuid;not null" json:"requested_by"`
+	ProcessedBy  *uuid.UUID   `gorm:"type:uuid" json:"processed_by"`
+	ProcessedAt  *time.Time   `json:"processed_at"`
+	Notes        string       `gorm:"type:text" json:"notes"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+
+	// Relations
+	Payment *Payment `gorm:"foreignKey:PaymentID" json:"payment,omitempty"`
+}
+
+func (r *Refund) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	if r.RefundNumber == "" {
+		r.RefundNumber = generateRefundNumber()
+	}
+	if r.Status == "" {
+		r.Status = RefundStatusPending
+	}
+	return nil
+}
+
+// generateRefundNumber creates unique refund number
+func generateRefundNumber() string {
+	return fmt.Sprintf("REF-%s-%s", time.Now().Format("20060102"), uuid.NewString()[:8])
+}
+
+// IsPending checks if refund is still waiting to be processed
+func (r *Refund) IsPending() bool {
+	return r.Status == RefundStatusPending
+}
+
+// MarkAsProcessed completes or fails a pending refund
+func (r *Refund) MarkAsProcessed(adminID uuid.UUID, status RefundStatus, notes string) error {
+	if !r.IsPending() {
+		return apperror.InvalidTransition(fmt.Sprintf("refund is already %s", r.Status))
+	}
+	r.Status = status
+	r.ProcessedBy = &adminID
+	now := time.Now()
+	r.ProcessedAt = &now
+	r.Notes = notes
+	return nil
+}
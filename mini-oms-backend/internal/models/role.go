@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Built-in roles; both are system roles and cannot be deleted
+const (
+	RoleAdmin = "admin" // holds every permission
+	RoleUser  = "user"  // customer account, no staff permissions
+)
+
+// Role is a named set of permissions assigned to users through User.Role
+type Role struct {
+	Name        string    `gorm:"type:varchar(50);primary_key" json:"name"`
+	Description string    `gorm:"type:varchar(255);not null;default:''" json:"description"`
+	IsSystem    bool      `gorm:"not null;default:false" json:"is_system"` // Built-in roles cannot be deleted
+	Permissions []string  `gorm:"-" json:"permissions"`                    // Loaded from role_permissions
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// RolePermission grants a permission (see package policy) to a role
+type RolePermission struct {
+	RoleName   string `gorm:"type:varchar(50);primary_key"`
+	Permission string `gorm:"type:varchar(100);primary_key"`
+}
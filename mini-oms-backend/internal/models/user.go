@@ -8,14 +8,17 @@ import (
 )
 
 type User struct {
-	ID        uuid.UUID      `gorm:"type:uuid;primary_key;" json:"id"`
-	Name      string         `gorm:"type:varchar(255);not null" json:"name"`
-	Email     string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"email"`
-	Password  string         `gorm:"type:varchar(255);not null" json:"-"`                  // Hidden from JSON
-	Role      string         `gorm:"type:varchar(20);not null;default:'user'" json:"role"` // 'user' or 'admin'
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"` // Soft delete
+	ID              uuid.UUID      `gorm:"type:uuid;primary_key;" json:"id"`
+	Name            string         `gorm:"type:varchar(255);not null" json:"name"`
+	Email           string         `gorm:"type:varchar(255);uniqueIndex:idx_users_email,where:deleted_at IS NULL;not null" json:"email"`
+	Password        string         `gorm:"type:varchar(255);not null" json:"-"`                  // Hidden from JSON
+	Role            string         `gorm:"type:varchar(50);not null;default:'user'" json:"role"` // Name of a Role, e.g. 'user', 'admin', 'support'
+	DisabledAt      *time.Time     `json:"disabled_at,omitempty"`                                // Disabled accounts cannot log in
+	EmailVerifiedAt *time.Time     `json:"email_verified_at"`                                    // Set once the user confirmed their email address
+	LockedUntil     *time.Time     `json:"locked_until,omitempty"`                               // Set after too many failed logins
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"` // Soft delete
 }
 
 // BeforeCreate hook to generate UUID
@@ -24,12 +27,27 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 		u.ID = uuid.New()
 	}
 	if u.Role == "" {
-		u.Role = "user"
+		u.Role = RoleUser
 	}
 	return nil
 }
 
 // IsAdmin checks if user has admin role
 func (u *User) IsAdmin() bool {
-	return u.Role == "admin"
+	return u.Role == RoleAdmin
+}
+
+// IsDisabled checks if account has been disabled by an operator
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
+
+// IsLocked checks if login is blocked after too many failed attempts
+func (u *User) IsLocked() bool {
+	return u.LockedUntil != nil && time.Now().Before(*u.LockedUntil)
+}
+
+// IsEmailVerified checks if the user confirmed their current email address
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
 }
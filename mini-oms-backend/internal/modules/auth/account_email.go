@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mini-oms-backend/internal/apperror"
+	"mini-oms-backend/internal/mailer"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/utils"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidUserToken = apperror.InvalidInput("token is invalid or has expired")
+	ErrEmailNotVerified = apperror.Forbidden("email address is not verified")
+)
+
+// ForgotPasswordRequest asks for a password reset link
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+// ResetPasswordRequest sets a new password with the token from a reset link
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6,max=72"`
+}
+
+// VerifyEmailRequest confirms an email address with the token from a verification link
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ResendVerificationRequest asks for a new verification link
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+// RequestPasswordReset mails a reset link. Unknown and disabled accounts are ignored
+// without an error, so the response does not reveal which emails are registered.
+func (s *Service) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.findMailRecipient(ctx, email)
+	if err != nil || user == nil {
+		return err
+	}
+
+	ttl := time.Duration(s.cfg.PasswordResetTokenTTLMinutes) * time.Minute
+	token, err := s.issueUserToken(ctx, user, models.UserTokenPasswordReset, user.Email, ttl)
+	if err != nil {
+		return err
+	}
+
+	s.sendMail(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %d minutes and can be used once.\n\n%s\n\nIf you did not ask for this, you can ignore this email.\n",
+			user.Name, s.cfg.PasswordResetTokenTTLMinutes, s.link("/reset-password", token)),
+	})
+	s.repo.LogAudit(ctx, user.ID, "PASSWORD_RESET_REQUESTED", "User", user.ID, "Password reset link sent to "+user.Email)
+	return nil
+}
+
+// CompletePasswordReset consumes a reset token, sets the new password and revokes every
+// session of the user. Receiving the link also proves the email address.
+func (s *Service) CompletePasswordReset(ctx context.Context, req *ResetPasswordRequest) error {
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Transaction(ctx, func(repo Repository) error {
+		token, user, err := consumeUserToken(ctx, repo, req.Token, models.UserTokenPasswordReset)
+		if err != nil {
+			return err
+		}
+
+		user.Password = hashedPassword
+		if user.EmailVerifiedAt == nil {
+			user.EmailVerifiedAt = token.UsedAt
+		}
+		if err := repo.Update(ctx, user); err != nil {
+			return err
+		}
+		if err := repo.RevokeUserTokens(ctx, user.ID); err != nil {
+			return err
+		}
+		return repo.LogAudit(ctx, user.ID, "PASSWORD_RESET_COMPLETED", "User", user.ID, "Password reset by email link, sessions revoked")
+	})
+}
+
+// VerifyEmail consumes a verification token and marks the email address as verified
+func (s *Service) VerifyEmail(ctx context.Context, req *VerifyEmailRequest) (*models.User, error) {
+	var user *models.User
+	err := s.repo.Transaction(ctx, func(repo Repository) error {
+		token, found, err := consumeUserToken(ctx, repo, req.Token, models.UserTokenEmailVerification)
+		if err != nil {
+			return err
+		}
+
+		user = found
+		user.EmailVerifiedAt = token.UsedAt
+		if err := repo.Update(ctx, user); err != nil {
+			return err
+		}
+		return repo.LogAudit(ctx, user.ID, "EMAIL_VERIFIED", "User", user.ID, "Email "+user.Email+" verified")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ResendVerification mails a new verification link to an unverified account. Like
+// RequestPasswordReset it does not reveal whether the email is registered.
+func (s *Service) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.findMailRecipient(ctx, email)
+	if err != nil || user == nil || user.IsEmailVerified() {
+		return err
+	}
+	return s.sendVerificationEmail(ctx, user)
+}
+
+// sendVerificationEmail mails a link that verifies the user's current email address
+func (s *Service) sendVerificationEmail(ctx context.Context, user *models.User) error {
+	ttl := time.Duration(s.cfg.EmailVerificationTokenTTLHours) * time.Hour
+	token, err := s.issueUserToken(ctx, user, models.UserTokenEmailVerification, user.Email, ttl)
+	if err != nil {
+		return err
+	}
+
+	s.sendMail(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address with the link below. It expires in %d hours.\n\n%s\n",
+			user.Name, s.cfg.EmailVerificationTokenTTLHours, s.link("/verify-email", token)),
+	})
+	return nil
+}
+
+// findMailRecipient returns the active account with email, or nil if there is none
+func (s *Service) findMailRecipient(ctx context.Context, email string) (*models.User, error) {
+	user, err := s.repo.FindByEmail(ctx, email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.IsDisabled() {
+		return nil, nil
+	}
+	return user, nil
+}
+
+// issueUserToken creates a token to be mailed to email; earlier unused tokens with the
+// same purpose stop working
+func (s *Service) issueUserToken(ctx context.Context, user *models.User, purpose, email string, ttl time.Duration) (string, error) {
+	plain, hash, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.repo.Transaction(ctx, func(repo Repository) error {
+		if err := repo.InvalidateUserTokens(ctx, user.ID, purpose); err != nil {
+			return err
+		}
+		return repo.CreateUserToken(ctx, &models.UserToken{
+			UserID:    user.ID,
+			Purpose:   purpose,
+			Email:     email,
+			TokenHash: hash,
+			ExpiresAt: time.Now().Add(ttl),
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+	return plain, nil
+}
+
+// consumeUserToken locks and marks a token as used within repo's transaction. Tokens sent to
+// an address the account no longer uses, or belonging to a disabled account, are invalid;
+// email change tokens are sent to the new address instead.
+func consumeUserToken(ctx context.Context, repo Repository, plain, purpose string) (*models.UserToken, *models.User, error) {
+	token, err := repo.FindUserTokenForUpdate(ctx, utils.HashToken(plain), purpose)
+	if err != nil {
+		return nil, nil, apperror.MapNotFound(err, ErrInvalidUserToken)
+	}
+	if !token.IsUsable() {
+		return nil, nil, ErrInvalidUserToken
+	}
+
+	user, err := repo.FindByID(ctx, token.UserID)
+	if err != nil {
+		return nil, nil, apperror.MapNotFound(err, ErrInvalidUserToken)
+	}
+	if (purpose != models.UserTokenEmailChange && user.Email != token.Email) || user.IsDisabled() {
+		return nil, nil, ErrInvalidUserToken
+	}
+
+	now := time.Now()
+	token.UsedAt = &now
+	if err := repo.UpdateUserToken(ctx, token); err != nil {
+		return nil, nil, err
+	}
+	return token, user, nil
+}
+
+// sendMail delivers msg, logging failures instead of returning them: the token stays
+// valid and the user can ask for another email
+func (s *Service) sendMail(ctx context.Context, msg mailer.Message) {
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		utils.LogError(ctx, "AuthService", msg.To, "SendMail", err, "Failed to send email: "+msg.Subject)
+	}
+}
+
+// link builds a frontend URL carrying token
+func (s *Service) link(path, token string) string {
+	return s.cfg.AppBaseURL + path + "?token=" + url.QueryEscape(token)
+}
@@ -1,9 +1,11 @@
 package auth
 
 import (
+	"mini-oms-backend/internal/policy"
 	"mini-oms-backend/internal/utils"
 	"net/http"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
@@ -29,21 +31,19 @@ func (h *Handler) Register(c echo.Context) error {
 		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
 	}
 
-	// Basic validation
-	if req.Name == "" || req.Email == "" || req.Password == "" {
-		return utils.ErrorResponse(c, http.StatusBadRequest, "Name, email, and password are required")
-	}
-
-	if len(req.Password) < 6 {
-		return utils.ErrorResponse(c, http.StatusBadRequest, "Password must be at least 6 characters")
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
 	}
 
 	// Register user
-	response, err := h.service.Register(&req)
+	response, err := h.service.Register(c.Request().Context(), &req)
 	if err != nil {
-		return utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return err
 	}
 
+	if response.AccessToken == "" {
+		return utils.SuccessResponse(c, http.StatusCreated, "User registered, check your email to verify the address before logging in", response)
+	}
 	return utils.SuccessResponse(c, http.StatusCreated, "User registered successfully", response)
 }
 
@@ -61,16 +61,368 @@ func (h *Handler) Login(c echo.Context) error {
 		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
 	}
 
-	// Basic validation
-	if req.Email == "" || req.Password == "" {
-		return utils.ErrorResponse(c, http.StatusBadRequest, "Email and password are required")
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
 	}
 
 	// Login user
-	response, err := h.service.Login(&req)
+	response, err := h.service.Login(c.Request().Context(), &req, c.RealIP())
 	if err != nil {
-		return utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return err
 	}
 
 	return utils.SuccessResponse(c, http.StatusOK, "Login successful", response)
 }
+
+// Refresh exchanges a refresh token for a new token pair
+// @Summary Refresh access token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RefreshRequest true "Refresh Request"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/auth/refresh [post]
+func (h *Handler) Refresh(c echo.Context) error {
+	var req RefreshRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	response, err := h.service.Refresh(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Token refreshed successfully", response)
+}
+
+// Logout revokes the current session
+// @Summary Logout user
+// @Tags auth
+// @Produce json
+// @Success 200 {object} utils.APIResponse
+// @Router /api/auth/logout [post]
+func (h *Handler) Logout(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	sessionID := c.Get("session_id").(uuid.UUID)
+
+	if err := h.service.Logout(c.Request().Context(), userID, sessionID); err != nil {
+		utils.LogError(c.Request().Context(), "AuthService", userID.String(), "Logout", err, "Failed to revoke session")
+		return utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to logout")
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Logout successful", nil)
+}
+
+// AssignRole changes the role of a user and logs them out of every session
+// @Summary Assign role to user
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body AssignRoleRequest true "Role"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/admin/users/{id}/role [put]
+func (h *Handler) AssignRole(c echo.Context) error {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	var req AssignRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	actor := policy.ActorFromContext(c)
+	user, err := h.service.AssignRole(c.Request().Context(), actor, userID, req.Role)
+	if err != nil {
+		return err
+	}
+
+	utils.LogInfo(c.Request().Context(), "AuthService", userID.String(), "AssignRole", "Role "+req.Role+" assigned by "+actor.UserID.String())
+	return utils.SuccessResponse(c, http.StatusOK, "Role assigned successfully", user)
+}
+
+// Unlock lifts the lockout of an account after too many failed logins
+// @Summary Unlock user account
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/admin/users/{id}/unlock [post]
+func (h *Handler) Unlock(c echo.Context) error {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	actorID := c.Get("user_id").(uuid.UUID)
+	user, err := h.service.Unlock(c.Request().Context(), actorID, userID)
+	if err != nil {
+		return err
+	}
+
+	utils.LogInfo(c.Request().Context(), "AuthService", userID.String(), "Unlock", "Account unlocked by "+actorID.String())
+	return utils.SuccessResponse(c, http.StatusOK, "Account unlocked successfully", user)
+}
+
+// ForgotPassword mails a password reset link. The response is the same whether or not
+// the email is registered.
+// @Summary Request password reset
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ForgotPasswordRequest true "Email"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/auth/password/forgot [post]
+func (h *Handler) ForgotPassword(c echo.Context) error {
+	var req ForgotPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	if err := h.service.RequestPasswordReset(c.Request().Context(), req.Email); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "If the email is registered, a password reset link has been sent", nil)
+}
+
+// ResetPassword sets a new password with a reset token and logs out every session
+// @Summary Reset password
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ResetPasswordRequest true "Token and new password"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/auth/password/reset [post]
+func (h *Handler) ResetPassword(c echo.Context) error {
+	var req ResetPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	if err := h.service.CompletePasswordReset(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Password reset successfully", nil)
+}
+
+// VerifyEmail confirms an email address with a verification token
+// @Summary Verify email address
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body VerifyEmailRequest true "Token"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/auth/email/verify [post]
+func (h *Handler) VerifyEmail(c echo.Context) error {
+	var req VerifyEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	user, err := h.service.VerifyEmail(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Email verified successfully", user)
+}
+
+// ResendVerification mails a new verification link to an unverified account
+// @Summary Resend verification email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ResendVerificationRequest true "Email"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/auth/email/resend [post]
+func (h *Handler) ResendVerification(c echo.Context) error {
+	var req ResendVerificationRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	if err := h.service.ResendVerification(c.Request().Context(), req.Email); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "If the email is registered and not yet verified, a verification link has been sent", nil)
+}
+
+// GetProfile returns the current user
+// @Summary Get current user
+// @Tags profile
+// @Produce json
+// @Success 200 {object} utils.APIResponse
+// @Router /api/me [get]
+func (h *Handler) GetProfile(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+
+	user, err := h.service.GetProfile(c.Request().Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Profile retrieved successfully", user)
+}
+
+// UpdateProfile changes the name of the current user
+// @Summary Update current user
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param request body UpdateProfileRequest true "Profile"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/me [put]
+func (h *Handler) UpdateProfile(c echo.Context) error {
+	var req UpdateProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	userID := c.Get("user_id").(uuid.UUID)
+	user, err := h.service.UpdateProfile(c.Request().Context(), userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Profile updated successfully", user)
+}
+
+// ChangeEmail mails a confirmation link to the new address; the email changes once it is used
+// @Summary Change email address
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param request body ChangeEmailRequest true "New email and current password"
+// @Success 202 {object} utils.APIResponse
+// @Router /api/me/email [post]
+func (h *Handler) ChangeEmail(c echo.Context) error {
+	var req ChangeEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	userID := c.Get("user_id").(uuid.UUID)
+	if err := h.service.RequestEmailChange(c.Request().Context(), userID, &req, c.RealIP()); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, http.StatusAccepted, "Check the new email address to confirm the change", nil)
+}
+
+// ConfirmEmailChange completes an email change with the token from the confirmation link
+// @Summary Confirm email change
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param request body ConfirmEmailChangeRequest true "Token"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/auth/email/change/confirm [post]
+func (h *Handler) ConfirmEmailChange(c echo.Context) error {
+	var req ConfirmEmailChangeRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	user, err := h.service.ConfirmEmailChange(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Email changed successfully", user)
+}
+
+// ChangePassword sets a new password and logs out every other session
+// @Summary Change password
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param request body ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/me/password [put]
+func (h *Handler) ChangePassword(c echo.Context) error {
+	var req ChangePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	userID := c.Get("user_id").(uuid.UUID)
+	sessionID := c.Get("session_id").(uuid.UUID)
+	if err := h.service.ChangePassword(c.Request().Context(), userID, sessionID, &req, c.RealIP()); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Password changed successfully, other sessions were logged out", nil)
+}
+
+// DeleteAccount deletes the current user's account and logs out every session
+// @Summary Delete account
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param request body DeleteAccountRequest true "Current password"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/me [delete]
+func (h *Handler) DeleteAccount(c echo.Context) error {
+	var req DeleteAccountRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	userID := c.Get("user_id").(uuid.UUID)
+	if err := h.service.DeleteAccount(c.Request().Context(), userID, &req, c.RealIP()); err != nil {
+		return err
+	}
+
+	utils.LogInfo(c.Request().Context(), "AuthService", userID.String(), "DeleteAccount", "Account deleted by its owner")
+	return utils.SuccessResponse(c, http.StatusOK, "Account deleted successfully", nil)
+}
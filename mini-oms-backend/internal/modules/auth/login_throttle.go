@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"mini-oms-backend/internal/apperror"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/utils"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Progressive delay between attempts once an account used its free attempts: 1s, 2s, 4s ...
+const (
+	loginBaseDelay = time.Second
+	loginMaxDelay  = time.Minute
+)
+
+var (
+	ErrLoginThrottled = apperror.TooManyRequests("too many failed login attempts, try again later")
+	ErrAccountLocked  = apperror.TooManyRequests("account is temporarily locked after too many failed login attempts")
+)
+
+// checkLoginThrottle rejects an attempt, before the password is checked, while the client
+// IP is blocked, the account is locked or the delay after its last failure has not passed.
+// user is nil for unknown emails, which are throttled like existing accounts.
+func (s *Service) checkLoginThrottle(ctx context.Context, email, ip string, user *models.User) error {
+	now := time.Now()
+	window := s.loginFailureWindow()
+
+	if s.cfg.LoginIPMaxFailures > 0 {
+		stats, err := s.repo.IPLoginFailures(ctx, ip, now.Add(-window))
+		if err != nil {
+			return err
+		}
+		if stats.Count >= int64(s.cfg.LoginIPMaxFailures) {
+			return apperror.RetryAfter(ErrLoginThrottled, stats.Last.Add(window).Sub(now))
+		}
+	}
+
+	if user != nil && user.IsLocked() {
+		return apperror.RetryAfter(ErrAccountLocked, user.LockedUntil.Sub(now))
+	}
+
+	stats, err := s.repo.AccountLoginFailures(ctx, email, now.Add(-window))
+	if err != nil {
+		return err
+	}
+	if wait := stats.Last.Add(loginDelay(stats.Count, s.cfg.LoginFreeAttempts)).Sub(now); wait > 0 {
+		return apperror.RetryAfter(ErrLoginThrottled, wait)
+	}
+	return nil
+}
+
+// recordLoginFailure stores a failed attempt and returns the error for the client: invalid
+// credentials, or a lockout once the account reached LoginMaxFailures
+func (s *Service) recordLoginFailure(ctx context.Context, email, ip string, user *models.User) error {
+	now := time.Now()
+	window := s.loginFailureWindow()
+
+	// Failures outside the window no longer count anywhere
+	if err := s.repo.DeleteLoginFailuresBefore(ctx, now.Add(-window)); err != nil {
+		return err
+	}
+	if err := s.repo.CreateLoginFailure(ctx, &models.LoginFailure{Email: email, IPAddress: ip}); err != nil {
+		return err
+	}
+
+	stats, err := s.repo.AccountLoginFailures(ctx, email, now.Add(-window))
+	if err != nil {
+		return err
+	}
+	if s.cfg.LoginMaxFailures <= 0 || stats.Count < int64(s.cfg.LoginMaxFailures) {
+		return ErrInvalidCredentials
+	}
+
+	lockout := time.Duration(s.cfg.LoginLockoutMinutes) * time.Minute
+	if user == nil {
+		return apperror.RetryAfter(ErrAccountLocked, lockout)
+	}
+	if err := s.lock(ctx, user, ip, stats.Count, now.Add(lockout)); err != nil {
+		return err
+	}
+	return apperror.RetryAfter(ErrAccountLocked, lockout)
+}
+
+// lock blocks logins to the account until the given time. Its failures are cleared, so
+// it gets its free attempts back once the lock expires.
+func (s *Service) lock(ctx context.Context, user *models.User, ip string, failures int64, until time.Time) error {
+	return s.repo.Transaction(ctx, func(repo Repository) error {
+		user.LockedUntil = &until
+		if err := repo.UpdateColumns(ctx, user, "locked_until"); err != nil {
+			return err
+		}
+		if err := repo.ClearLoginFailures(ctx, user.Email); err != nil {
+			return err
+		}
+
+		details := fmt.Sprintf("Locked until %s after %d failed logins, last from %s", until.Format(time.RFC3339), failures, ip)
+		utils.LogInfo(ctx, "AuthService", user.ID.String(), "Login", details)
+		return repo.LogAudit(ctx, models.SystemActorID, "ACCOUNT_LOCKED", "User", user.ID, details)
+	})
+}
+
+// Unlock lifts a lockout and forgives the account's failed logins
+func (s *Service) Unlock(ctx context.Context, actorID, userID uuid.UUID) (*models.User, error) {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, apperror.MapNotFound(err, ErrUserNotFound)
+	}
+
+	err = s.repo.Transaction(ctx, func(repo Repository) error {
+		user.LockedUntil = nil
+		if err := repo.UpdateColumns(ctx, user, "locked_until"); err != nil {
+			return err
+		}
+		if err := repo.ClearLoginFailures(ctx, user.Email); err != nil {
+			return err
+		}
+		return repo.LogAudit(ctx, actorID, "ACCOUNT_UNLOCKED", "User", user.ID, "Account "+user.Email)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *Service) loginFailureWindow() time.Duration {
+	return time.Duration(s.cfg.LoginFailureWindowMinutes) * time.Minute
+}
+
+// loginDelay is the wait after the last of failures before the next attempt
+func loginDelay(failures int64, free int) time.Duration {
+	extra := failures - int64(free)
+	if extra < 0 {
+		return 0
+	}
+	if extra >= 16 { // avoid overflowing the shift
+		return loginMaxDelay
+	}
+	return min(loginBaseDelay<<extra, loginMaxDelay)
+}
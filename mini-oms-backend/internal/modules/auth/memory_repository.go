@@ -0,0 +1,329 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"mini-oms-backend/internal/memstore"
+	"mini-oms-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// memoryRepository implements Repository on top of memstore, for tests
+type memoryRepository struct {
+	store *memstore.Store
+}
+
+func NewMemoryRepository(store *memstore.Store) Repository {
+	return &memoryRepository{store: store}
+}
+
+func (r *memoryRepository) Transaction(ctx context.Context, fn func(repo Repository) error) error {
+	return r.store.Transaction(ctx, func() error {
+		return fn(r)
+	})
+}
+
+func (r *memoryRepository) Create(ctx context.Context, user *models.User) error {
+	return r.store.InsertUser(user)
+}
+
+func (r *memoryRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	user, ok := r.store.FindUserByEmail(email)
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &user, nil
+}
+
+func (r *memoryRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	user, ok := r.store.Users[id]
+	if !ok || user.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &user, nil
+}
+
+func (r *memoryRepository) Update(ctx context.Context, user *models.User) error {
+	user.UpdatedAt = time.Now()
+
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	r.store.Users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryRepository) UpdateColumns(ctx context.Context, user *models.User, columns ...string) error {
+	user.UpdatedAt = time.Now()
+
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	stored, ok := r.store.Users[user.ID]
+	if !ok {
+		return nil
+	}
+	for _, column := range columns {
+		switch column {
+		case "name":
+			stored.Name = user.Name
+		case "email":
+			stored.Email = user.Email
+		case "password":
+			stored.Password = user.Password
+		case "role":
+			stored.Role = user.Role
+		case "disabled_at":
+			stored.DisabledAt = user.DisabledAt
+		case "email_verified_at":
+			stored.EmailVerifiedAt = user.EmailVerifiedAt
+		case "locked_until":
+			stored.LockedUntil = user.LockedUntil
+		default:
+			return fmt.Errorf("memory repository: unknown user column %q", column)
+		}
+	}
+	stored.UpdatedAt = user.UpdatedAt
+	r.store.Users[user.ID] = stored
+	return nil
+}
+
+func (r *memoryRepository) Delete(ctx context.Context, user *models.User) error {
+	user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	r.store.Users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryRepository) EmailExists(ctx context.Context, email string) bool {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	_, ok := r.store.FindUserByEmail(email)
+	return ok
+}
+
+func (r *memoryRepository) FindRole(ctx context.Context, name string) (*models.Role, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	role, ok := r.store.Roles[name]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &role, nil
+}
+
+func (r *memoryRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	return r.insertToken(token)
+}
+
+// insertToken stores a new refresh token (store must be locked)
+func (r *memoryRepository) insertToken(token *models.RefreshToken) error {
+	if err := token.BeforeCreate(nil); err != nil {
+		return err
+	}
+	token.CreatedAt = time.Now()
+	r.store.RefreshTokens[token.ID] = *token
+	return nil
+}
+
+func (r *memoryRepository) FindRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	for _, token := range r.store.RefreshTokens {
+		if token.TokenHash == hash {
+			return &token, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryRepository) RotateRefreshToken(ctx context.Context, old *models.RefreshToken, next *models.RefreshToken) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	current, ok := r.store.RefreshTokens[old.ID]
+	if !ok || current.RevokedAt != nil {
+		return ErrTokenAlreadyRotated
+	}
+	if err := r.insertToken(next); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	current.RevokedAt = &now
+	current.ReplacedByID = &next.ID
+	r.store.RefreshTokens[current.ID] = current
+	return nil
+}
+
+func (r *memoryRepository) RevokeTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	r.revokeTokens(func(token models.RefreshToken) bool { return token.FamilyID == familyID })
+	return nil
+}
+
+func (r *memoryRepository) HasActiveToken(ctx context.Context, familyID uuid.UUID) bool {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	for _, token := range r.store.RefreshTokens {
+		if token.FamilyID == familyID && token.IsActive() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryRepository) RevokeUserTokens(ctx context.Context, userID uuid.UUID) error {
+	r.revokeTokens(func(token models.RefreshToken) bool { return token.UserID == userID })
+	return nil
+}
+
+func (r *memoryRepository) RevokeOtherUserTokens(ctx context.Context, userID, familyID uuid.UUID) error {
+	r.revokeTokens(func(token models.RefreshToken) bool { return token.UserID == userID && token.FamilyID != familyID })
+	return nil
+}
+
+// revokeTokens revokes every active token matching the predicate
+func (r *memoryRepository) revokeTokens(match func(token models.RefreshToken) bool) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	now := time.Now()
+	for id, token := range r.store.RefreshTokens {
+		if token.RevokedAt == nil && match(token) {
+			token.RevokedAt = &now
+			r.store.RefreshTokens[id] = token
+		}
+	}
+}
+
+func (r *memoryRepository) CreateUserToken(ctx context.Context, token *models.UserToken) error {
+	if err := token.BeforeCreate(nil); err != nil {
+		return err
+	}
+	token.CreatedAt = time.Now()
+	return r.UpdateUserToken(ctx, token)
+}
+
+func (r *memoryRepository) FindUserTokenForUpdate(ctx context.Context, hash, purpose string) (*models.UserToken, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	for _, token := range r.store.UserTokens {
+		if token.TokenHash == hash && token.Purpose == purpose {
+			return &token, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryRepository) UpdateUserToken(ctx context.Context, token *models.UserToken) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	r.store.UserTokens[token.ID] = *token
+	return nil
+}
+
+func (r *memoryRepository) InvalidateUserTokens(ctx context.Context, userID uuid.UUID, purpose string) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	now := time.Now()
+	for id, token := range r.store.UserTokens {
+		if token.UserID == userID && token.Purpose == purpose && token.UsedAt == nil {
+			token.UsedAt = &now
+			r.store.UserTokens[id] = token
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepository) CreateLoginFailure(ctx context.Context, failure *models.LoginFailure) error {
+	if err := failure.BeforeCreate(nil); err != nil {
+		return err
+	}
+	failure.CreatedAt = time.Now()
+
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	r.store.LoginFailures[failure.ID] = *failure
+	return nil
+}
+
+func (r *memoryRepository) AccountLoginFailures(ctx context.Context, email string, since time.Time) (*LoginFailureStats, error) {
+	return r.loginFailureStats(since, func(failure models.LoginFailure) bool {
+		return failure.Email == email && failure.ClearedAt == nil
+	}), nil
+}
+
+func (r *memoryRepository) IPLoginFailures(ctx context.Context, ip string, since time.Time) (*LoginFailureStats, error) {
+	return r.loginFailureStats(since, func(failure models.LoginFailure) bool {
+		return failure.IPAddress == ip
+	}), nil
+}
+
+func (r *memoryRepository) loginFailureStats(since time.Time, match func(failure models.LoginFailure) bool) *LoginFailureStats {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	stats := &LoginFailureStats{}
+	for _, failure := range r.store.LoginFailures {
+		if failure.CreatedAt.After(since) && match(failure) {
+			stats.Count++
+			if failure.CreatedAt.After(stats.Last) {
+				stats.Last = failure.CreatedAt
+			}
+		}
+	}
+	return stats
+}
+
+func (r *memoryRepository) ClearLoginFailures(ctx context.Context, email string) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	now := time.Now()
+	for id, failure := range r.store.LoginFailures {
+		if failure.Email == email && failure.ClearedAt == nil {
+			failure.ClearedAt = &now
+			r.store.LoginFailures[id] = failure
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepository) DeleteLoginFailuresBefore(ctx context.Context, before time.Time) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	for id, failure := range r.store.LoginFailures {
+		if failure.CreatedAt.Before(before) {
+			delete(r.store.LoginFailures, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepository) LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error {
+	return r.store.LogAudit(ctx, userID, action, entityName, entityID, details)
+}
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mini-oms-backend/internal/apperror"
+	"mini-oms-backend/internal/mailer"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/utils"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrWrongPassword = apperror.InvalidInput("current password is incorrect")
+	ErrSameEmail     = apperror.InvalidInput("new email is the same as the current one")
+)
+
+// UpdateProfileRequest changes the profile of the current user
+type UpdateProfileRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+// ChangeEmailRequest asks to move the account to a new email address
+type ChangeEmailRequest struct {
+	Email           string `json:"email" validate:"required,email,max=255"`
+	CurrentPassword string `json:"current_password" validate:"required"`
+}
+
+// ChangePasswordRequest sets a new password for the current user
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6,max=72"`
+}
+
+// DeleteAccountRequest confirms deleting the current user's account
+type DeleteAccountRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+}
+
+// ConfirmEmailChangeRequest completes an email change with the token from the confirmation link
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// GetProfile returns the current user
+func (s *Service) GetProfile(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, apperror.MapNotFound(err, ErrUserNotFound)
+	}
+	return user, nil
+}
+
+// UpdateProfile changes the name of the current user
+func (s *Service) UpdateProfile(ctx context.Context, userID uuid.UUID, req *UpdateProfileRequest) (*models.User, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Name = req.Name
+	if err := s.repo.UpdateColumns(ctx, user, "name"); err != nil {
+		return nil, err
+	}
+
+	s.repo.LogAudit(ctx, user.ID, "PROFILE_UPDATED", "User", user.ID, "Name changed")
+	return user, nil
+}
+
+// RequestEmailChange mails a confirmation link to the new address. The account keeps its
+// current email until the link is used, so a mistyped address cannot lock the user out.
+func (s *Service) RequestEmailChange(ctx context.Context, userID uuid.UUID, req *ChangeEmailRequest, clientIP string) error {
+	user, err := s.authenticate(ctx, userID, req.CurrentPassword, clientIP)
+	if err != nil {
+		return err
+	}
+	if req.Email == user.Email {
+		return ErrSameEmail
+	}
+	if s.repo.EmailExists(ctx, req.Email) {
+		return ErrEmailRegistered
+	}
+
+	ttl := time.Duration(s.cfg.EmailVerificationTokenTTLHours) * time.Hour
+	token, err := s.issueUserToken(ctx, user, models.UserTokenEmailChange, req.Email, ttl)
+	if err != nil {
+		return err
+	}
+
+	s.sendMail(ctx, mailer.Message{
+		To:      req.Email,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to make %s the email address of your account. It expires in %d hours.\n\n%s\n",
+			user.Name, req.Email, s.cfg.EmailVerificationTokenTTLHours, s.link("/confirm-email", token)),
+	})
+	s.repo.LogAudit(ctx, user.ID, "EMAIL_CHANGE_REQUESTED", "User", user.ID, "Confirmation sent to "+req.Email)
+	return nil
+}
+
+// ConfirmEmailChange moves the account to the address the token was sent to, which is
+// verified by receiving it. The previous address is notified.
+func (s *Service) ConfirmEmailChange(ctx context.Context, req *ConfirmEmailChangeRequest) (*models.User, error) {
+	var (
+		user     *models.User
+		oldEmail string
+	)
+	err := s.repo.Transaction(ctx, func(repo Repository) error {
+		token, found, err := consumeUserToken(ctx, repo, req.Token, models.UserTokenEmailChange)
+		if err != nil {
+			return err
+		}
+		if repo.EmailExists(ctx, token.Email) {
+			return ErrEmailRegistered
+		}
+
+		user, oldEmail = found, found.Email
+		user.Email = token.Email
+		user.EmailVerifiedAt = token.UsedAt
+		if err := repo.Update(ctx, user); err != nil {
+			return err
+		}
+		return repo.LogAudit(ctx, user.ID, "EMAIL_CHANGED", "User", user.ID, "Email changed from "+oldEmail+" to "+user.Email)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.sendMail(ctx, mailer.Message{
+		To:      oldEmail,
+		Subject: "Your email address was changed",
+		Body: fmt.Sprintf("Hi %s,\n\nThe email address of your account was changed to %s. If you did not do this, please contact support.\n",
+			user.Name, user.Email),
+	})
+	return user, nil
+}
+
+// ChangePassword sets a new password after checking the current one and logs out every
+// other session; the session making the change stays active
+func (s *Service) ChangePassword(ctx context.Context, userID, sessionID uuid.UUID, req *ChangePasswordRequest, clientIP string) error {
+	user, err := s.authenticate(ctx, userID, req.CurrentPassword, clientIP)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Transaction(ctx, func(repo Repository) error {
+		user.Password = hashedPassword
+		if err := repo.UpdateColumns(ctx, user, "password"); err != nil {
+			return err
+		}
+		if err := repo.RevokeOtherUserTokens(ctx, user.ID, sessionID); err != nil {
+			return err
+		}
+		if err := repo.InvalidateUserTokens(ctx, user.ID, models.UserTokenPasswordReset); err != nil {
+			return err
+		}
+		return repo.LogAudit(ctx, user.ID, "PASSWORD_CHANGED", "User", user.ID, "Password changed, other sessions revoked")
+	})
+}
+
+// DeleteAccount soft deletes the current user's account and ends all of its sessions.
+// Orders and payments are kept; the email can be registered again.
+func (s *Service) DeleteAccount(ctx context.Context, userID uuid.UUID, req *DeleteAccountRequest, clientIP string) error {
+	user, err := s.authenticate(ctx, userID, req.CurrentPassword, clientIP)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Transaction(ctx, func(repo Repository) error {
+		if err := repo.Delete(ctx, user); err != nil {
+			return err
+		}
+		if err := repo.RevokeUserTokens(ctx, user.ID); err != nil {
+			return err
+		}
+		for _, purpose := range []string{models.UserTokenPasswordReset, models.UserTokenEmailVerification, models.UserTokenEmailChange} {
+			if err := repo.InvalidateUserTokens(ctx, user.ID, purpose); err != nil {
+				return err
+			}
+		}
+		return repo.LogAudit(ctx, user.ID, "ACCOUNT_DELETED", "User", user.ID, "Account "+user.Email+" deleted by its owner")
+	})
+}
+
+// authenticate loads the current user and checks their password. Wrong passwords count as
+// failed logins, so a stolen access token cannot be used to guess the password.
+func (s *Service) authenticate(ctx context.Context, userID uuid.UUID, password, clientIP string) (*models.User, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkLoginThrottle(ctx, user.Email, clientIP, user); err != nil {
+		return nil, err
+	}
+	if !utils.CheckPassword(user.Password, password) {
+		err := s.recordLoginFailure(ctx, user.Email, clientIP, user)
+		if errors.Is(err, ErrInvalidCredentials) {
+			return nil, ErrWrongPassword
+		}
+		return nil, err
+	}
+	if err := s.repo.ClearLoginFailures(ctx, user.Email); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -1,29 +1,89 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/utils"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-type Repository struct {
+// Repository is the user and session data access used by Service.
+// Methods named ...ForUpdate lock the row and must be called within Transaction.
+type Repository interface {
+	// Transaction runs fn as a unit of work; repo is bound to the transaction
+	Transaction(ctx context.Context, fn func(repo Repository) error) error
+
+	Create(ctx context.Context, user *models.User) error
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	Update(ctx context.Context, user *models.User) error
+	// UpdateColumns writes only the given columns of user, so concurrent changes to other
+	// columns are not overwritten with stale values
+	UpdateColumns(ctx context.Context, user *models.User, columns ...string) error
+	Delete(ctx context.Context, user *models.User) error // soft delete
+	EmailExists(ctx context.Context, email string) bool
+	FindRole(ctx context.Context, name string) (*models.Role, error)
+
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	FindRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, old *models.RefreshToken, next *models.RefreshToken) error
+	RevokeTokenFamily(ctx context.Context, familyID uuid.UUID) error
+	HasActiveToken(ctx context.Context, familyID uuid.UUID) bool
+	RevokeUserTokens(ctx context.Context, userID uuid.UUID) error
+	// RevokeOtherUserTokens revokes every active session of a user except familyID
+	RevokeOtherUserTokens(ctx context.Context, userID, familyID uuid.UUID) error
+
+	CreateUserToken(ctx context.Context, token *models.UserToken) error
+	FindUserTokenForUpdate(ctx context.Context, hash, purpose string) (*models.UserToken, error)
+	UpdateUserToken(ctx context.Context, token *models.UserToken) error
+	// InvalidateUserTokens marks the unused tokens of a user with the given purpose as used
+	InvalidateUserTokens(ctx context.Context, userID uuid.UUID, purpose string) error
+
+	CreateLoginFailure(ctx context.Context, failure *models.LoginFailure) error
+	// AccountLoginFailures summarizes the uncleared failures of an email since the given time
+	AccountLoginFailures(ctx context.Context, email string, since time.Time) (*LoginFailureStats, error)
+	// IPLoginFailures summarizes every failure from a client IP since the given time
+	IPLoginFailures(ctx context.Context, ip string, since time.Time) (*LoginFailureStats, error)
+	ClearLoginFailures(ctx context.Context, email string) error
+	DeleteLoginFailuresBefore(ctx context.Context, before time.Time) error
+
+	LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error
+}
+
+// LoginFailureStats summarizes a set of failed logins
+type LoginFailureStats struct {
+	Count int64
+	Last  time.Time // zero when Count is 0
+}
+
+type gormRepository struct {
 	db *gorm.DB
 }
 
-func NewRepository(db *gorm.DB) *Repository {
-	return &Repository{db: db}
+func NewRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) Transaction(ctx context.Context, fn func(repo Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&gormRepository{db: tx})
+	})
 }
 
 // Create creates new user
-func (r *Repository) Create(user *models.User) error {
-	return r.db.Create(user).Error
+func (r *gormRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
 }
 
 // FindByEmail finds user by email
-func (r *Repository) FindByEmail(email string) (*models.User, error) {
+func (r *gormRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -31,18 +91,186 @@ func (r *Repository) FindByEmail(email string) (*models.User, error) {
 }
 
 // FindByID finds user by ID
-func (r *Repository) FindByID(id uuid.UUID) (*models.User, error) {
+func (r *gormRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	var user models.User
-	err := r.db.First(&user, "id = ?", id).Error
+	err := r.db.WithContext(ctx).First(&user, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
+// Update saves user changes
+func (r *gormRepository) Update(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Save(user).Error
+}
+
+// UpdateColumns saves the given user columns only
+func (r *gormRepository) UpdateColumns(ctx context.Context, user *models.User, columns ...string) error {
+	return r.db.WithContext(ctx).Model(user).Select(columns).Updates(user).Error
+}
+
+// Delete soft deletes user
+func (r *gormRepository) Delete(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Delete(user).Error
+}
+
 // EmailExists checks if email already exists
-func (r *Repository) EmailExists(email string) bool {
+func (r *gormRepository) EmailExists(ctx context.Context, email string) bool {
+	var count int64
+	r.db.WithContext(ctx).Model(&models.User{}).Where("email = ?", email).Count(&count)
+	return count > 0
+}
+
+// FindRole finds a role with its permissions
+func (r *gormRepository) FindRole(ctx context.Context, name string) (*models.Role, error) {
+	var role models.Role
+	if err := r.db.WithContext(ctx).First(&role, "name = ?", name).Error; err != nil {
+		return nil, err
+	}
+	err := r.db.WithContext(ctx).Model(&models.RolePermission{}).
+		Where("role_name = ?", name).Order("permission").Pluck("permission", &role.Permissions).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// CreateRefreshToken stores a new refresh token
+func (r *gormRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// FindRefreshTokenByHash finds refresh token by its hash
+func (r *gormRepository) FindRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.WithContext(ctx).First(&token, "token_hash = ?", hash).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ErrTokenAlreadyRotated is returned when a refresh token was used concurrently
+var ErrTokenAlreadyRotated = errors.New("refresh token already rotated")
+
+// RotateRefreshToken marks old token as replaced and stores its successor atomically
+func (r *gormRepository) RotateRefreshToken(ctx context.Context, old *models.RefreshToken, next *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+
+		// Guard on revoked_at so only one concurrent refresh can win
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", old.ID).
+			Updates(map[string]interface{}{"revoked_at": time.Now(), "replaced_by_id": next.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTokenAlreadyRotated
+		}
+		return nil
+	})
+}
+
+// RevokeTokenFamily revokes every active token of a session
+func (r *gormRepository) RevokeTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// HasActiveToken checks if a session still has a usable refresh token
+func (r *gormRepository) HasActiveToken(ctx context.Context, familyID uuid.UUID) bool {
 	var count int64
-	r.db.Model(&models.User{}).Where("email = ?", email).Count(&count)
+	r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL AND expires_at > ?", familyID, time.Now()).
+		Count(&count)
 	return count > 0
 }
+
+// RevokeUserTokens revokes every active session of a user
+func (r *gormRepository) RevokeUserTokens(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *gormRepository) RevokeOtherUserTokens(ctx context.Context, userID, familyID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// CreateUserToken stores a new single-use token
+func (r *gormRepository) CreateUserToken(ctx context.Context, token *models.UserToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// FindUserTokenForUpdate finds a token by hash and purpose and locks the row
+func (r *gormRepository) FindUserTokenForUpdate(ctx context.Context, hash, purpose string) (*models.UserToken, error) {
+	var token models.UserToken
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&token, "token_hash = ? AND purpose = ?", hash, purpose).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *gormRepository) UpdateUserToken(ctx context.Context, token *models.UserToken) error {
+	return r.db.WithContext(ctx).Save(token).Error
+}
+
+func (r *gormRepository) InvalidateUserTokens(ctx context.Context, userID uuid.UUID, purpose string) error {
+	return r.db.WithContext(ctx).Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
+
+func (r *gormRepository) CreateLoginFailure(ctx context.Context, failure *models.LoginFailure) error {
+	return r.db.WithContext(ctx).Create(failure).Error
+}
+
+func (r *gormRepository) AccountLoginFailures(ctx context.Context, email string, since time.Time) (*LoginFailureStats, error) {
+	return r.loginFailureStats(ctx, "email = ? AND cleared_at IS NULL AND created_at > ?", email, since)
+}
+
+func (r *gormRepository) IPLoginFailures(ctx context.Context, ip string, since time.Time) (*LoginFailureStats, error) {
+	return r.loginFailureStats(ctx, "ip_address = ? AND created_at > ?", ip, since)
+}
+
+func (r *gormRepository) loginFailureStats(ctx context.Context, query string, args ...interface{}) (*LoginFailureStats, error) {
+	var row struct {
+		Count int64
+		Last  *time.Time
+	}
+	err := r.db.WithContext(ctx).Model(&models.LoginFailure{}).
+		Select("COUNT(*) AS count, MAX(created_at) AS last").
+		Where(query, args...).Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &LoginFailureStats{Count: row.Count}
+	if row.Last != nil {
+		stats.Last = *row.Last
+	}
+	return stats, nil
+}
+
+func (r *gormRepository) ClearLoginFailures(ctx context.Context, email string) error {
+	return r.db.WithContext(ctx).Model(&models.LoginFailure{}).
+		Where("email = ? AND cleared_at IS NULL", email).
+		Update("cleared_at", time.Now()).Error
+}
+
+func (r *gormRepository) DeleteLoginFailuresBefore(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("created_at < ?", before).Delete(&models.LoginFailure{}).Error
+}
+
+func (r *gormRepository) LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error {
+	return utils.LogAudit(r.db.WithContext(ctx), userID, action, entityName, entityID, details)
+}
@@ -1,30 +1,49 @@
 package auth
 
 import (
+	"context"
 	"errors"
+	"mini-oms-backend/internal/apperror"
 	"mini-oms-backend/internal/config"
+	"mini-oms-backend/internal/mailer"
 	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/policy"
 	"mini-oms-backend/internal/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Service struct {
-	repo *Repository
-	cfg  *config.Config
+	repo   Repository
+	cfg    *config.Config
+	mailer mailer.Mailer
 }
 
-func NewService(repo *Repository, cfg *config.Config) *Service {
+func NewService(repo Repository, cfg *config.Config, mailer mailer.Mailer) *Service {
 	return &Service{
-		repo: repo,
-		cfg:  cfg,
+		repo:   repo,
+		cfg:    cfg,
+		mailer: mailer,
 	}
 }
 
+var (
+	ErrInvalidCredentials  = apperror.Unauthorized("invalid credentials")
+	ErrInvalidRefreshToken = apperror.Unauthorized("invalid refresh token")
+	ErrAccountDisabled     = apperror.Forbidden("account is disabled")
+	ErrEmailRegistered     = apperror.Conflict("email already registered")
+	ErrUserNotFound        = apperror.NotFound("user not found")
+	ErrInvalidRole         = apperror.InvalidInput("invalid role")
+	ErrRoleNotGrantable    = apperror.Forbidden("cannot assign a role with permissions you do not hold")
+)
+
 // RegisterRequest represents registration request
 type RegisterRequest struct {
-	Name     string `json:"name" validate:"required"`
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
-	Role     string `json:"role"` // Optional, defaults to "user"
+	Name     string `json:"name" validate:"required,max=255"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,min=6,max=72"`
 }
 
 // LoginRequest represents login request
@@ -33,19 +52,31 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
-// AuthResponse represents authentication response
+// RefreshRequest represents token refresh request
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// AssignRoleRequest represents a role change of a user
+type AssignRoleRequest struct {
+	Role string `json:"role" validate:"required,max=50"`
+}
+
+// AuthResponse represents authentication response. Tokens are omitted after registering
+// while email verification is required.
 type AuthResponse struct {
-	User        *models.User `json:"user"`
-	AccessToken string       `json:"access_token"`
-	TokenType   string       `json:"token_type"`
-	ExpiresIn   int          `json:"expires_in"`
+	User         *models.User `json:"user"`
+	AccessToken  string       `json:"access_token,omitempty"`
+	RefreshToken string       `json:"refresh_token,omitempty"`
+	TokenType    string       `json:"token_type,omitempty"`
+	ExpiresIn    int          `json:"expires_in,omitempty"`
 }
 
 // Register registers new user
-func (s *Service) Register(req *RegisterRequest) (*AuthResponse, error) {
+func (s *Service) Register(ctx context.Context, req *RegisterRequest) (*AuthResponse, error) {
 	// Check if email already exists
-	if s.repo.EmailExists(req.Email) {
-		return nil, errors.New("email already registered")
+	if s.repo.EmailExists(ctx, req.Email) {
+		return nil, ErrEmailRegistered
 	}
 
 	// Hash password
@@ -54,64 +85,330 @@ func (s *Service) Register(req *RegisterRequest) (*AuthResponse, error) {
 		return nil, err
 	}
 
-	// Validate and set role
-	role := "user" // Default role
-	if req.Role != "" {
-		// Validate role (only "user" or "admin" allowed)
-		if req.Role == "user" || req.Role == "admin" {
-			role = req.Role
-		}
-	}
-
-	// Create user
+	// Public registration always creates a regular user; admins join via invitation
 	user := &models.User{
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: hashedPassword,
-		Role:     role,
+		Role:     models.RoleUser,
 	}
 
-	if err := s.repo.Create(user); err != nil {
+	if err := s.repo.Create(ctx, user); err != nil {
 		return nil, err
 	}
 
-	// Generate JWT token
-	token, err := utils.GenerateJWT(s.cfg, user.ID, user.Email, user.Role)
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		utils.LogError(ctx, "AuthService", user.ID.String(), "Register", err, "Failed to issue email verification token")
+	}
+	if s.cfg.RequireEmailVerification {
+		return &AuthResponse{User: user}, nil
+	}
+
+	// Start a new session
+	return s.issueTokens(ctx, user, uuid.New())
+}
+
+// Login authenticates user and returns token. Failed attempts are throttled per account
+// and per client IP, see checkLoginThrottle.
+func (s *Service) Login(ctx context.Context, req *LoginRequest, clientIP string) (*AuthResponse, error) {
+	// Find user by email; unknown emails are throttled the same way
+	user, err := s.repo.FindByEmail(ctx, req.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err := s.checkLoginThrottle(ctx, req.Email, clientIP, user); err != nil {
+		return nil, err
+	}
+
+	// Check password
+	if user == nil || !utils.CheckPassword(user.Password, req.Password) {
+		return nil, s.recordLoginFailure(ctx, req.Email, clientIP, user)
+	}
+	if err := s.repo.ClearLoginFailures(ctx, req.Email); err != nil {
+		return nil, err
+	}
+
+	if user.IsDisabled() {
+		return nil, ErrAccountDisabled
+	}
+	if s.cfg.RequireEmailVerification && !user.IsEmailVerified() {
+		return nil, ErrEmailNotVerified
+	}
+
+	// Start a new session
+	return s.issueTokens(ctx, user, uuid.New())
+}
+
+// Refresh exchanges a refresh token for a new token pair (rotation).
+// Presenting a token that was already rotated revokes the whole session.
+func (s *Service) Refresh(ctx context.Context, req *RefreshRequest) (*AuthResponse, error) {
+	current, err := s.repo.FindRefreshTokenByHash(ctx, utils.HashToken(req.RefreshToken))
+	if err != nil {
+		return nil, apperror.MapNotFound(err, ErrInvalidRefreshToken)
+	}
+
+	if current.RevokedAt != nil {
+		// A rotated token came back: assume it was stolen and kill the session
+		if current.ReplacedByID != nil {
+			s.revokeOnReuse(ctx, current)
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if !current.IsActive() {
+		return nil, apperror.Unauthorized("refresh token expired")
+	}
+
+	user, err := s.repo.FindByID(ctx, current.UserID)
+	if err != nil {
+		return nil, apperror.MapNotFound(err, ErrInvalidRefreshToken)
+	}
+	if user.IsDisabled() || (s.cfg.RequireEmailVerification && !user.IsEmailVerified()) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	plain, next, err := s.newRefreshToken(ctx, user.ID, current.FamilyID)
 	if err != nil {
 		return nil, err
 	}
 
-	return &AuthResponse{
-		User:        user,
-		AccessToken: token,
-		TokenType:   "Bearer",
-		ExpiresIn:   s.cfg.JWTExpiryHours * 3600, // Convert to seconds
-	}, nil
+	if err := s.repo.RotateRefreshToken(ctx, current, next); err != nil {
+		if errors.Is(err, ErrTokenAlreadyRotated) {
+			s.revokeOnReuse(ctx, current)
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	return s.buildResponse(ctx, user, current.FamilyID, plain)
 }
 
-// Login authenticates user and returns token
-func (s *Service) Login(req *LoginRequest) (*AuthResponse, error) {
-	// Find user by email
-	user, err := s.repo.FindByEmail(req.Email)
+// Logout revokes the session the access token belongs to
+func (s *Service) Logout(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if err := s.repo.RevokeTokenFamily(ctx, sessionID); err != nil {
+		return err
+	}
+	return s.repo.LogAudit(ctx, userID, "USER_LOGOUT", "User", userID, "Session "+sessionID.String()+" revoked")
+}
+
+// CreateUser creates an account with any role (operator use, e.g. omsctl)
+func (s *Service) CreateUser(ctx context.Context, actorID uuid.UUID, name, email, password, role string) (*models.User, error) {
+	if name == "" || email == "" || len(password) < 6 {
+		return nil, apperror.InvalidInput("name, email and a password of at least 6 characters are required")
+	}
+	if _, err := s.findRole(ctx, role); err != nil {
+		return nil, err
+	}
+	if s.repo.EmailExists(ctx, email) {
+		return nil, ErrEmailRegistered
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		return nil, err
 	}
 
-	// Check password
-	if !utils.CheckPassword(user.Password, req.Password) {
-		return nil, errors.New("invalid credentials")
+	// Operators vouch for the address
+	now := time.Now()
+	user := &models.User{
+		Name:            name,
+		Email:           email,
+		Password:        hashedPassword,
+		Role:            role,
+		EmailVerifiedAt: &now,
+	}
+	if err := s.repo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	s.repo.LogAudit(ctx, actorID, "USER_CREATED", "User", user.ID, "User created with role "+role)
+	return user, nil
+}
+
+// SetRole changes a user's role (operator use, e.g. omsctl)
+func (s *Service) SetRole(ctx context.Context, actorID uuid.UUID, email, role string) (*models.User, error) {
+	next, err := s.findRole(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, apperror.MapNotFound(err, ErrUserNotFound)
+	}
+
+	return s.changeRole(ctx, actorID, user, next)
+}
+
+// AssignRole changes the role of a user. The actor must hold every permission of both the
+// current and the new role, so nobody can hand out or take away more access than they have.
+func (s *Service) AssignRole(ctx context.Context, actor policy.Actor, userID uuid.UUID, role string) (*models.User, error) {
+	next, err := s.findRole(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, apperror.MapNotFound(err, ErrUserNotFound)
+	}
+
+	current, err := s.repo.FindRole(ctx, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.CanGrant(next.Permissions) || !actor.CanGrant(current.Permissions) {
+		return nil, ErrRoleNotGrantable
+	}
+
+	return s.changeRole(ctx, actor.UserID, user, next)
+}
+
+// changeRole saves the new role and revokes the user's sessions in one transaction so new
+// tokens carry its permissions
+func (s *Service) changeRole(ctx context.Context, actorID uuid.UUID, user *models.User, role *models.Role) (*models.User, error) {
+	previous := user.Role
+	err := s.repo.Transaction(ctx, func(repo Repository) error {
+		user.Role = role.Name
+		if err := repo.UpdateColumns(ctx, user, "role"); err != nil {
+			return err
+		}
+		if err := repo.RevokeUserTokens(ctx, user.ID); err != nil {
+			return err
+		}
+		return repo.LogAudit(ctx, actorID, "USER_ROLE_CHANGED", "User", user.ID, "Role changed from "+previous+" to "+role.Name)
+	})
+	if err != nil {
+		user.Role = previous
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *Service) findRole(ctx context.Context, name string) (*models.Role, error) {
+	role, err := s.repo.FindRole(ctx, name)
+	if err != nil {
+		return nil, apperror.MapNotFound(err, ErrInvalidRole)
+	}
+	return role, nil
+}
+
+// SetDisabled disables (and logs out) or re-enables an account
+func (s *Service) SetDisabled(ctx context.Context, actorID uuid.UUID, email string, disabled bool) (*models.User, error) {
+	user, err := s.repo.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, apperror.MapNotFound(err, ErrUserNotFound)
+	}
+
+	action := "USER_ENABLED"
+	if disabled {
+		action = "USER_DISABLED"
+		now := time.Now()
+		user.DisabledAt = &now
+	} else {
+		user.DisabledAt = nil
+	}
+
+	if err := s.repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	if disabled {
+		if err := s.repo.RevokeUserTokens(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	s.repo.LogAudit(ctx, actorID, action, "User", user.ID, "Account "+user.Email)
+	return user, nil
+}
+
+// ResetPassword sets a new password and revokes all sessions of the user
+func (s *Service) ResetPassword(ctx context.Context, actorID uuid.UUID, email, password string) error {
+	if len(password) < 6 {
+		return apperror.InvalidInput("password must be at least 6 characters")
+	}
+
+	user, err := s.repo.FindByEmail(ctx, email)
+	if err != nil {
+		return apperror.MapNotFound(err, ErrUserNotFound)
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	user.Password = hashedPassword
+	if err := s.repo.Update(ctx, user); err != nil {
+		return err
+	}
+	if err := s.repo.RevokeUserTokens(ctx, user.ID); err != nil {
+		return err
+	}
+
+	return s.repo.LogAudit(ctx, actorID, "USER_PASSWORD_RESET", "User", user.ID, "Password reset by operator")
+}
+
+// IsSessionActive implements middlewares.SessionChecker
+func (s *Service) IsSessionActive(ctx context.Context, sessionID uuid.UUID) bool {
+	return s.repo.HasActiveToken(ctx, sessionID)
+}
+
+// issueTokens creates a refresh token in the given family and a matching access token
+func (s *Service) issueTokens(ctx context.Context, user *models.User, familyID uuid.UUID) (*AuthResponse, error) {
+	plain, token, err := s.newRefreshToken(ctx, user.ID, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateRefreshToken(ctx, token); err != nil {
+		return nil, err
+	}
+
+	return s.buildResponse(ctx, user, familyID, plain)
+}
+
+func (s *Service) newRefreshToken(ctx context.Context, userID, familyID uuid.UUID) (string, *models.RefreshToken, error) {
+	plain, hash, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return plain, &models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(time.Duration(s.cfg.RefreshTokenExpiryHours) * time.Hour),
+	}, nil
+}
+
+func (s *Service) buildResponse(ctx context.Context, user *models.User, sessionID uuid.UUID, refreshToken string) (*AuthResponse, error) {
+	// Embed the permissions of the user's role
+	role, err := s.repo.FindRole(ctx, user.Role)
+	if err != nil {
+		return nil, err
 	}
 
 	// Generate JWT token
-	token, err := utils.GenerateJWT(s.cfg, user.ID, user.Email, user.Role)
+	token, err := utils.GenerateJWT(s.cfg, user.ID, user.Email, user.Role, role.Permissions, sessionID)
 	if err != nil {
 		return nil, err
 	}
 
 	return &AuthResponse{
-		User:        user,
-		AccessToken: token,
-		TokenType:   "Bearer",
-		ExpiresIn:   s.cfg.JWTExpiryHours * 3600,
+		User:         user,
+		AccessToken:  token,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    s.cfg.JWTExpiryHours * 3600, // Convert to seconds
 	}, nil
 }
+
+func (s *Service) revokeOnReuse(ctx context.Context, token *models.RefreshToken) {
+	if err := s.repo.RevokeTokenFamily(ctx, token.FamilyID); err != nil {
+		utils.LogError(ctx, "AuthService", token.UserID.String(), "RefreshToken", err, "Failed to revoke token family")
+		return
+	}
+	s.repo.LogAudit(ctx, token.UserID, "REFRESH_TOKEN_REUSE", "User", token.UserID, "Rotated refresh token reused, session "+token.FamilyID.String()+" revoked")
+}
@@ -0,0 +1,364 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"mini-oms-backend/internal/apperror"
+	"mini-oms-backend/internal/config"
+	"mini-oms-backend/internal/mailer"
+	"mini-oms-backend/internal/memstore"
+	"net/url"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeMailer records messages instead of sending them
+type fakeMailer struct {
+	sent []mailer.Message
+}
+
+func (m *fakeMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+const clientIP = "203.0.113.1"
+
+var tokenPattern = regexp.MustCompile(`token=(\S+)`)
+
+// lastToken extracts the token from the link in the last message sent
+func (m *fakeMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	if len(m.sent) == 0 {
+		t.Fatal("no email sent")
+	}
+	match := tokenPattern.FindStringSubmatch(m.sent[len(m.sent)-1].Body)
+	if match == nil {
+		t.Fatal("no token link in email")
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatalf("unescape token: %v", err)
+	}
+	return token
+}
+
+func newTestService(requireVerification bool) (*Service, *fakeMailer, *memstore.Store) {
+	store := memstore.New()
+	mail := &fakeMailer{}
+	cfg := &config.Config{
+		JWTSecret:                      "test-secret",
+		JWTExpiryHours:                 1,
+		RefreshTokenExpiryHours:        24,
+		AppBaseURL:                     "http://localhost:3000",
+		PasswordResetTokenTTLMinutes:   60,
+		EmailVerificationTokenTTLHours: 48,
+		RequireEmailVerification:       requireVerification,
+		LoginFailureWindowMinutes:      15,
+		LoginFreeAttempts:              3,
+		LoginMaxFailures:               5,
+		LoginLockoutMinutes:            15,
+		LoginIPMaxFailures:             20,
+	}
+	return NewService(NewMemoryRepository(store), cfg, mail), mail, store
+}
+
+func TestPasswordResetTokenIsSingleUseAndRevokesSessions(t *testing.T) {
+	service, mail, store := newTestService(false)
+	ctx := context.Background()
+
+	registered, err := service.Register(ctx, &RegisterRequest{Name: "Buyer", Email: "buyer@example.com", Password: "old-secret"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if err := service.RequestPasswordReset(ctx, "buyer@example.com"); err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	token := mail.lastToken(t)
+
+	if err := service.CompletePasswordReset(ctx, &ResetPasswordRequest{Token: token, Password: "new-secret"}); err != nil {
+		t.Fatalf("CompletePasswordReset: %v", err)
+	}
+	if err := service.CompletePasswordReset(ctx, &ResetPasswordRequest{Token: token, Password: "other-secret"}); !errors.Is(err, ErrInvalidUserToken) {
+		t.Fatalf("reused token: err = %v, want ErrInvalidUserToken", err)
+	}
+
+	for _, rt := range store.RefreshTokens {
+		if rt.UserID == registered.User.ID && rt.RevokedAt == nil {
+			t.Error("session survived password reset")
+		}
+	}
+	if _, err := service.Login(ctx, &LoginRequest{Email: "buyer@example.com", Password: "old-secret"}, clientIP); err == nil {
+		t.Error("old password still accepted")
+	}
+	if _, err := service.Login(ctx, &LoginRequest{Email: "buyer@example.com", Password: "new-secret"}, clientIP); err != nil {
+		t.Errorf("Login with new password: %v", err)
+	}
+}
+
+func TestRequestPasswordResetForUnknownEmailSendsNothing(t *testing.T) {
+	service, mail, _ := newTestService(false)
+
+	if err := service.RequestPasswordReset(context.Background(), "nobody@example.com"); err != nil {
+		t.Fatalf("err = %v, want nil", err)
+	}
+	if len(mail.sent) != 0 {
+		t.Fatalf("sent %d emails, want 0", len(mail.sent))
+	}
+}
+
+func TestLoginRequiresVerifiedEmail(t *testing.T) {
+	service, mail, _ := newTestService(true)
+	ctx := context.Background()
+
+	registered, err := service.Register(ctx, &RegisterRequest{Name: "Buyer", Email: "buyer@example.com", Password: "secret"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if registered.AccessToken != "" {
+		t.Error("tokens issued before verification")
+	}
+
+	login := &LoginRequest{Email: "buyer@example.com", Password: "secret"}
+	if _, err := service.Login(ctx, login, clientIP); !errors.Is(err, ErrEmailNotVerified) {
+		t.Fatalf("err = %v, want ErrEmailNotVerified", err)
+	}
+
+	user, err := service.VerifyEmail(ctx, &VerifyEmailRequest{Token: mail.lastToken(t)})
+	if err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if !user.IsEmailVerified() {
+		t.Error("email not marked verified")
+	}
+	if _, err := service.Login(ctx, login, clientIP); err != nil {
+		t.Fatalf("Login after verification: %v", err)
+	}
+}
+
+// ageLoginFailures moves every recorded failure back in time, as if the client waited
+func ageLoginFailures(store *memstore.Store, d time.Duration) {
+	store.Lock()
+	defer store.Unlock()
+	for id, failure := range store.LoginFailures {
+		failure.CreatedAt = failure.CreatedAt.Add(-d)
+		store.LoginFailures[id] = failure
+	}
+}
+
+func TestLoginDelaysAttemptsAfterFreeFailures(t *testing.T) {
+	service, _, _ := newTestService(false)
+	ctx := context.Background()
+	wrong := &LoginRequest{Email: "nobody@example.com", Password: "guess"}
+
+	for i := 0; i < 3; i++ {
+		if _, err := service.Login(ctx, wrong, clientIP); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalidCredentials", i+1, err)
+		}
+	}
+
+	_, err := service.Login(ctx, wrong, clientIP)
+	var retry *apperror.RetryError
+	if !errors.Is(err, ErrLoginThrottled) || !errors.As(err, &retry) {
+		t.Fatalf("err = %v, want ErrLoginThrottled with a retry delay", err)
+	}
+	if retry.After <= 0 || retry.After > time.Second {
+		t.Errorf("retry after %v, want up to 1s", retry.After)
+	}
+}
+
+func TestLoginLocksAccountUntilUnlocked(t *testing.T) {
+	service, _, store := newTestService(false)
+	ctx := context.Background()
+
+	registered, err := service.Register(ctx, &RegisterRequest{Name: "Buyer", Email: "buyer@example.com", Password: "secret"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	userID := registered.User.ID
+
+	wrong := &LoginRequest{Email: "buyer@example.com", Password: "guess"}
+	for i := 1; i < 5; i++ {
+		if _, err := service.Login(ctx, wrong, clientIP); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalidCredentials", i, err)
+		}
+		ageLoginFailures(store, time.Minute)
+	}
+	if _, err := service.Login(ctx, wrong, clientIP); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("attempt 5: err = %v, want ErrAccountLocked", err)
+	}
+
+	// The right password does not help while locked
+	right := &LoginRequest{Email: "buyer@example.com", Password: "secret"}
+	if _, err := service.Login(ctx, right, clientIP); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("locked: err = %v, want ErrAccountLocked", err)
+	}
+	if !slices.Contains(store.AuditActions(userID), "ACCOUNT_LOCKED") {
+		t.Errorf("audit = %v, want ACCOUNT_LOCKED", store.AuditActions(userID))
+	}
+
+	if _, err := service.Unlock(ctx, uuid.New(), userID); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if _, err := service.Login(ctx, right, clientIP); err != nil {
+		t.Fatalf("Login after unlock: %v", err)
+	}
+}
+
+func TestLoginBlocksClientIPAcrossAccounts(t *testing.T) {
+	service, _, store := newTestService(false)
+	ctx := context.Background()
+	service.cfg.LoginIPMaxFailures = 3
+
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		if _, err := service.Login(ctx, &LoginRequest{Email: email, Password: "guess"}, clientIP); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("%s: err = %v, want ErrInvalidCredentials", email, err)
+		}
+	}
+
+	if _, err := service.Login(ctx, &LoginRequest{Email: "d@example.com", Password: "guess"}, clientIP); !errors.Is(err, ErrLoginThrottled) {
+		t.Fatalf("blocked IP: err = %v, want ErrLoginThrottled", err)
+	}
+	if _, err := service.Login(ctx, &LoginRequest{Email: "d@example.com", Password: "guess"}, "198.51.100.7"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("other IP: err = %v, want ErrInvalidCredentials", err)
+	}
+
+	// The block lifts once the failures leave the window
+	ageLoginFailures(store, 16*time.Minute)
+	if _, err := service.Login(ctx, &LoginRequest{Email: "e@example.com", Password: "guess"}, clientIP); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("after window: err = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestChangePasswordKeepsOnlyCurrentSession(t *testing.T) {
+	service, _, store := newTestService(false)
+	ctx := context.Background()
+
+	registered, err := service.Register(ctx, &RegisterRequest{Name: "Buyer", Email: "buyer@example.com", Password: "old-secret"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := service.Login(ctx, &LoginRequest{Email: "buyer@example.com", Password: "old-secret"}, clientIP); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	userID := registered.User.ID
+
+	var current uuid.UUID
+	for _, rt := range store.RefreshTokens {
+		current = rt.FamilyID
+		break
+	}
+
+	wrong := &ChangePasswordRequest{CurrentPassword: "guess", NewPassword: "new-secret"}
+	if err := service.ChangePassword(ctx, userID, current, wrong, clientIP); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("err = %v, want ErrWrongPassword", err)
+	}
+
+	req := &ChangePasswordRequest{CurrentPassword: "old-secret", NewPassword: "new-secret"}
+	if err := service.ChangePassword(ctx, userID, current, req, clientIP); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+	for _, rt := range store.RefreshTokens {
+		if revoked := rt.RevokedAt != nil; revoked != (rt.FamilyID != current) {
+			t.Errorf("session %s revoked = %v", rt.FamilyID, revoked)
+		}
+	}
+	if _, err := service.Login(ctx, &LoginRequest{Email: "buyer@example.com", Password: "new-secret"}, clientIP); err != nil {
+		t.Errorf("Login with new password: %v", err)
+	}
+}
+
+func TestEmailChangeTakesEffectOnConfirmation(t *testing.T) {
+	service, mail, _ := newTestService(false)
+	ctx := context.Background()
+
+	registered, err := service.Register(ctx, &RegisterRequest{Name: "Buyer", Email: "old@example.com", Password: "secret"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	userID := registered.User.ID
+
+	if err := service.RequestEmailChange(ctx, userID, &ChangeEmailRequest{Email: "new@example.com", CurrentPassword: "secret"}, clientIP); err != nil {
+		t.Fatalf("RequestEmailChange: %v", err)
+	}
+	if to := mail.sent[len(mail.sent)-1].To; to != "new@example.com" {
+		t.Fatalf("confirmation sent to %s, want new address", to)
+	}
+	if user, _ := service.GetProfile(ctx, userID); user.Email != "old@example.com" {
+		t.Fatalf("email = %s before confirmation", user.Email)
+	}
+
+	user, err := service.ConfirmEmailChange(ctx, &ConfirmEmailChangeRequest{Token: mail.lastToken(t)})
+	if err != nil {
+		t.Fatalf("ConfirmEmailChange: %v", err)
+	}
+	if user.Email != "new@example.com" || !user.IsEmailVerified() {
+		t.Errorf("user = %s verified=%v, want verified new@example.com", user.Email, user.IsEmailVerified())
+	}
+	if to := mail.sent[len(mail.sent)-1].To; to != "old@example.com" {
+		t.Errorf("notice sent to %s, want old address", to)
+	}
+	if _, err := service.Login(ctx, &LoginRequest{Email: "new@example.com", Password: "secret"}, clientIP); err != nil {
+		t.Errorf("Login with new email: %v", err)
+	}
+}
+
+func TestDeleteAccountEndsSessionsAndFreesEmail(t *testing.T) {
+	service, _, store := newTestService(false)
+	ctx := context.Background()
+
+	registered, err := service.Register(ctx, &RegisterRequest{Name: "Buyer", Email: "buyer@example.com", Password: "secret"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	userID := registered.User.ID
+
+	if err := service.DeleteAccount(ctx, userID, &DeleteAccountRequest{CurrentPassword: "secret"}, clientIP); err != nil {
+		t.Fatalf("DeleteAccount: %v", err)
+	}
+	if !store.Users[userID].DeletedAt.Valid {
+		t.Error("account not soft deleted")
+	}
+	for _, rt := range store.RefreshTokens {
+		if rt.RevokedAt == nil {
+			t.Error("session survived account deletion")
+		}
+	}
+	if _, err := service.Login(ctx, &LoginRequest{Email: "buyer@example.com", Password: "secret"}, clientIP); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Login: err = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := service.Register(ctx, &RegisterRequest{Name: "Buyer", Email: "buyer@example.com", Password: "secret"}); err != nil {
+		t.Errorf("Register with freed email: %v", err)
+	}
+}
+
+func TestWrongCurrentPasswordIsThrottled(t *testing.T) {
+	service, _, _ := newTestService(false)
+	ctx := context.Background()
+
+	registered, err := service.Register(ctx, &RegisterRequest{Name: "Buyer", Email: "buyer@example.com", Password: "secret"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	userID := registered.User.ID
+
+	guess := &DeleteAccountRequest{CurrentPassword: "guess"}
+	for i := 0; i < 3; i++ {
+		if err := service.DeleteAccount(ctx, userID, guess, clientIP); !errors.Is(err, ErrWrongPassword) {
+			t.Fatalf("attempt %d: err = %v, want ErrWrongPassword", i+1, err)
+		}
+	}
+
+	// Guessing through the profile endpoints counts against the account like failed logins
+	if err := service.DeleteAccount(ctx, userID, &DeleteAccountRequest{CurrentPassword: "secret"}, clientIP); !errors.Is(err, ErrLoginThrottled) {
+		t.Fatalf("err = %v, want ErrLoginThrottled", err)
+	}
+	if _, err := service.Login(ctx, &LoginRequest{Email: "buyer@example.com", Password: "secret"}, clientIP); !errors.Is(err, ErrLoginThrottled) {
+		t.Fatalf("Login: err = %v, want ErrLoginThrottled", err)
+	}
+}
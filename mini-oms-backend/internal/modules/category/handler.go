@@ -0,0 +1,108 @@
+package category
+
+import (
+	"fmt"
+	"mini-oms-backend/internal/utils"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetAll returns all categories with product counts
+func (h *Handler) GetAll(c echo.Context) error {
+	categories, err := h.service.GetAll(c.Request().Context())
+	if err != nil {
+		utils.LogError(c.Request().Context(), "CategoryService", "", "GetAllCategories", err, "Failed to fetch categories")
+		return utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch categories")
+	}
+
+	utils.LogInfo(c.Request().Context(), "CategoryService", "", "GetAllCategories", fmt.Sprintf("Retrieved %d categories", len(categories)))
+	return utils.SuccessResponse(c, http.StatusOK, "Categories retrieved successfully", categories)
+}
+
+// GetByID returns category by ID
+func (h *Handler) GetByID(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid category ID")
+	}
+
+	category, err := h.service.GetByID(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Category retrieved successfully", category)
+}
+
+// Create creates new category (catalog:write)
+func (h *Handler) Create(c echo.Context) error {
+	var req CategoryRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	adminID := c.Get("user_id").(uuid.UUID)
+
+	category, err := h.service.Create(c.Request().Context(), adminID, &req)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, http.StatusCreated, "Category created successfully", category)
+}
+
+// Update updates category (catalog:write)
+func (h *Handler) Update(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid category ID")
+	}
+
+	var req CategoryRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	adminID := c.Get("user_id").(uuid.UUID)
+
+	category, err := h.service.Update(c.Request().Context(), adminID, id, &req)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Category updated successfully", category)
+}
+
+// Delete deletes an empty category (catalog:write)
+func (h *Handler) Delete(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid category ID")
+	}
+
+	adminID := c.Get("user_id").(uuid.UUID)
+
+	if err := h.service.Delete(c.Request().Context(), adminID, id); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Category deleted successfully", nil)
+}
@@ -0,0 +1,125 @@
+package category
+
+import (
+	"context"
+	"mini-oms-backend/internal/memstore"
+	"mini-oms-backend/internal/models"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// memoryRepository implements Repository on top of memstore, for tests
+type memoryRepository struct {
+	store *memstore.Store
+}
+
+func NewMemoryRepository(store *memstore.Store) Repository {
+	return &memoryRepository{store: store}
+}
+
+func (r *memoryRepository) FindAllWithProductCount(ctx context.Context) ([]CategoryWithCount, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	var categories []CategoryWithCount
+	for _, category := range r.store.Categories {
+		if category.DeletedAt.Valid {
+			continue
+		}
+		categories = append(categories, CategoryWithCount{
+			ID:           category.ID,
+			Name:         category.Name,
+			Description:  category.Description,
+			ProductCount: r.countProducts(category.ID),
+			CreatedAt:    category.CreatedAt,
+			UpdatedAt:    category.UpdatedAt,
+		})
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].Name < categories[j].Name })
+	return categories, nil
+}
+
+func (r *memoryRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Category, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	category, ok := r.store.Categories[id]
+	if !ok || category.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &category, nil
+}
+
+func (r *memoryRepository) NameExists(ctx context.Context, name string, excludeID uuid.UUID) bool {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	for _, category := range r.store.Categories {
+		if !category.DeletedAt.Valid && category.ID != excludeID && strings.EqualFold(category.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryRepository) CountProducts(ctx context.Context, id uuid.UUID) (int64, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	return r.countProducts(id), nil
+}
+
+// countProducts counts non-deleted products of a category (store must be locked)
+func (r *memoryRepository) countProducts(id uuid.UUID) int64 {
+	var count int64
+	for _, product := range r.store.Products {
+		if !product.DeletedAt.Valid && product.CategoryID != nil && *product.CategoryID == id {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *memoryRepository) Create(ctx context.Context, category *models.Category) error {
+	if err := category.BeforeCreate(nil); err != nil {
+		return err
+	}
+	now := time.Now()
+	category.CreatedAt = now
+	category.UpdatedAt = now
+	return r.save(category)
+}
+
+func (r *memoryRepository) Update(ctx context.Context, category *models.Category) error {
+	category.UpdatedAt = time.Now()
+	return r.save(category)
+}
+
+func (r *memoryRepository) save(category *models.Category) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	row := *category
+	row.Products = nil
+	r.store.Categories[category.ID] = row
+	return nil
+}
+
+func (r *memoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	if category, ok := r.store.Categories[id]; ok {
+		category.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		r.store.Categories[id] = category
+	}
+	return nil
+}
+
+func (r *memoryRepository) LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error {
+	return r.store.LogAudit(ctx, userID, action, entityName, entityID, details)
+}
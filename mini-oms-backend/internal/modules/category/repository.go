@@ -0,0 +1,90 @@
+package category
+
+import (
+	"context"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Repository is the category data access used by Service
+type Repository interface {
+	FindAllWithProductCount(ctx context.Context) ([]CategoryWithCount, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Category, error)
+	NameExists(ctx context.Context, name string, excludeID uuid.UUID) bool
+	CountProducts(ctx context.Context, id uuid.UUID) (int64, error)
+	Create(ctx context.Context, category *models.Category) error
+	Update(ctx context.Context, category *models.Category) error
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error
+}
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+// CategoryWithCount is a category along with the number of products in it
+type CategoryWithCount struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	ProductCount int64     `json:"product_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// FindAllWithProductCount lists categories with their (non-deleted) product counts
+func (r *gormRepository) FindAllWithProductCount(ctx context.Context) ([]CategoryWithCount, error) {
+	var categories []CategoryWithCount
+	err := r.db.WithContext(ctx).Model(&models.Category{}).
+		Select("categories.id, categories.name, categories.description, categories.created_at, categories.updated_at, COUNT(products.id) AS product_count").
+		Joins("LEFT JOIN products ON products.category_id = categories.id AND products.deleted_at IS NULL").
+		Group("categories.id").
+		Order("categories.name ASC").
+		Scan(&categories).Error
+	return categories, err
+}
+
+func (r *gormRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Category, error) {
+	var category models.Category
+	err := r.db.WithContext(ctx).First(&category, "id = ?", id).Error
+	return &category, err
+}
+
+// NameExists checks if another category already uses the name (case-insensitive)
+func (r *gormRepository) NameExists(ctx context.Context, name string, excludeID uuid.UUID) bool {
+	var count int64
+	r.db.WithContext(ctx).Model(&models.Category{}).Where("LOWER(name) = LOWER(?) AND id <> ?", name, excludeID).Count(&count)
+	return count > 0
+}
+
+// CountProducts counts products that still belong to the category
+func (r *gormRepository) CountProducts(ctx context.Context, id uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Product{}).Where("category_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+func (r *gormRepository) Create(ctx context.Context, category *models.Category) error {
+	return r.db.WithContext(ctx).Create(category).Error
+}
+
+func (r *gormRepository) Update(ctx context.Context, category *models.Category) error {
+	return r.db.WithContext(ctx).Save(category).Error
+}
+
+func (r *gormRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.Category{}, "id = ?", id).Error
+}
+
+func (r *gormRepository) LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error {
+	return utils.LogAudit(r.db.WithContext(ctx), userID, action, entityName, entityID, details)
+}
@@ -0,0 +1,114 @@
+package category
+
+import (
+	"context"
+	"fmt"
+	"mini-oms-backend/internal/apperror"
+	"mini-oms-backend/internal/models"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+type CategoryRequest struct {
+	Name        string `json:"name" validate:"required,max=255"`
+	Description string `json:"description" validate:"max=1000"`
+}
+
+var (
+	ErrCategoryNotFound = apperror.NotFound("category not found")
+	// ErrCategoryNotEmpty is returned when deleting a category that still has products
+	ErrCategoryNotEmpty = apperror.Conflict("category still has products")
+)
+
+func (s *Service) GetAll(ctx context.Context) ([]CategoryWithCount, error) {
+	return s.repo.FindAllWithProductCount(ctx)
+}
+
+func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*models.Category, error) {
+	category, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, apperror.MapNotFound(err, ErrCategoryNotFound)
+	}
+	return category, nil
+}
+
+func (s *Service) Create(ctx context.Context, adminID uuid.UUID, req *CategoryRequest) (*models.Category, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, apperror.InvalidInput("category name is required")
+	}
+
+	if s.repo.NameExists(ctx, name, uuid.Nil) {
+		return nil, apperror.Conflict("category name already exists")
+	}
+
+	category := &models.Category{
+		Name:        name,
+		Description: req.Description,
+	}
+
+	if err := s.repo.Create(ctx, category); err != nil {
+		return nil, err
+	}
+
+	s.repo.LogAudit(ctx, adminID, "CATEGORY_CREATED", "Category", category.ID, "Category created: "+category.Name)
+	return category, nil
+}
+
+func (s *Service) Update(ctx context.Context, adminID, id uuid.UUID, req *CategoryRequest) (*models.Category, error) {
+	category, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, apperror.MapNotFound(err, ErrCategoryNotFound)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, apperror.InvalidInput("category name is required")
+	}
+
+	if s.repo.NameExists(ctx, name, id) {
+		return nil, apperror.Conflict("category name already exists")
+	}
+
+	category.Name = name
+	category.Description = req.Description
+
+	if err := s.repo.Update(ctx, category); err != nil {
+		return nil, err
+	}
+
+	s.repo.LogAudit(ctx, adminID, "CATEGORY_UPDATED", "Category", category.ID, "Category updated: "+category.Name)
+	return category, nil
+}
+
+// Delete removes a category, refusing while products are still assigned to it
+func (s *Service) Delete(ctx context.Context, adminID, id uuid.UUID) error {
+	category, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return apperror.MapNotFound(err, ErrCategoryNotFound)
+	}
+
+	count, err := s.repo.CountProducts(ctx, id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %d product(s) must be moved or deleted first", ErrCategoryNotEmpty, count)
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.repo.LogAudit(ctx, adminID, "CATEGORY_DELETED", "Category", category.ID, "Category deleted: "+category.Name)
+	return nil
+}
@@ -0,0 +1,44 @@
+package health
+
+import (
+	"mini-oms-backend/internal/apperror"
+	"mini-oms-backend/internal/utils"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Liveness reports that the process is up; it does not touch dependencies
+// @Summary Liveness probe
+// @Tags health
+// @Produce json
+// @Success 200 {object} utils.APIResponse
+// @Router /healthz [get]
+func (h *Handler) Liveness(c echo.Context) error {
+	return utils.SuccessResponse(c, http.StatusOK, "OK", nil)
+}
+
+// Readiness reports whether the instance can serve traffic (database reachable, schema up to date)
+// @Summary Readiness probe
+// @Tags health
+// @Produce json
+// @Success 200 {object} utils.APIResponse
+// @Failure 503 {object} utils.APIResponse
+// @Router /readyz [get]
+func (h *Handler) Readiness(c echo.Context) error {
+	checks, ready := h.service.Ready(c.Request().Context())
+	if !ready {
+		utils.LogError(c.Request().Context(), "HealthService", "", "Readiness", nil, "Instance not ready")
+		return utils.CodedErrorResponse(c, http.StatusServiceUnavailable, apperror.CodeServiceUnavailable, "Not ready", checks)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Ready", checks)
+}
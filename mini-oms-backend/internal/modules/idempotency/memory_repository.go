@@ -0,0 +1,90 @@
+package idempotency
+
+import (
+	"context"
+	"mini-oms-backend/internal/memstore"
+	"mini-oms-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// memoryRepository implements Repository on top of memstore, for tests
+type memoryRepository struct {
+	store *memstore.Store
+}
+
+func NewMemoryRepository(store *memstore.Store) Repository {
+	return &memoryRepository{store: store}
+}
+
+func (r *memoryRepository) Insert(ctx context.Context, key *models.IdempotencyKey) (bool, error) {
+	if err := key.BeforeCreate(nil); err != nil {
+		return false, err
+	}
+
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	if _, ok := r.find(key.UserID, key.Route, key.Key); ok {
+		return false, nil
+	}
+	key.CreatedAt = time.Now()
+	r.store.IdempotencyKeys[key.ID] = *key
+	return true, nil
+}
+
+func (r *memoryRepository) Find(ctx context.Context, userID uuid.UUID, route, key string) (*models.IdempotencyKey, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	record, ok := r.find(userID, route, key)
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &record, nil
+}
+
+func (r *memoryRepository) find(userID uuid.UUID, route, key string) (models.IdempotencyKey, bool) {
+	for _, record := range r.store.IdempotencyKeys {
+		if record.UserID == userID && record.Route == route && record.Key == key {
+			return record, true
+		}
+	}
+	return models.IdempotencyKey{}, false
+}
+
+func (r *memoryRepository) Complete(ctx context.Context, id uuid.UUID, statusCode int, body string) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	if record, ok := r.store.IdempotencyKeys[id]; ok {
+		record.StatusCode = statusCode
+		record.ResponseBody = body
+		r.store.IdempotencyKeys[id] = record
+	}
+	return nil
+}
+
+func (r *memoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	delete(r.store.IdempotencyKeys, id)
+	return nil
+}
+
+func (r *memoryRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	var deleted int64
+	for id, record := range r.store.IdempotencyKeys {
+		if !now.Before(record.ExpiresAt) {
+			delete(r.store.IdempotencyKeys, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,56 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"mini-oms-backend/internal/utils"
+	"time"
+)
+
+// PurgeWorker periodically deletes expired idempotency keys
+type PurgeWorker struct {
+	service  *Service
+	interval time.Duration
+}
+
+func NewPurgeWorker(service *Service, interval time.Duration) *PurgeWorker {
+	return &PurgeWorker{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Start runs the worker in the background until ctx is canceled.
+// The returned channel is closed once the worker has stopped.
+func (w *PurgeWorker) Start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.purge(ctx)
+			}
+		}
+	}()
+
+	return done
+}
+
+func (w *PurgeWorker) purge(ctx context.Context) {
+	deleted, err := w.service.PurgeExpired(ctx)
+	if err != nil && ctx.Err() == nil {
+		utils.LogError(ctx, "IdempotencyPurgeWorker", "", "Purge", err, "Failed to delete expired idempotency keys")
+		return
+	}
+	if deleted > 0 {
+		utils.LogInfo(ctx, "IdempotencyPurgeWorker", "", "Purge", fmt.Sprintf("Deleted %d expired idempotency key(s)", deleted))
+	}
+}
@@ -0,0 +1,59 @@
+package idempotency
+
+import (
+	"context"
+	"mini-oms-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository is the idempotency key data access used by Service.
+// Keys are written outside of the transaction of the request they protect, so a
+// claim is visible to concurrent retries right away.
+type Repository interface {
+	// Insert stores a new key and reports false when the key is already taken
+	Insert(ctx context.Context, key *models.IdempotencyKey) (bool, error)
+	Find(ctx context.Context, userID uuid.UUID, route, key string) (*models.IdempotencyKey, error)
+	Complete(ctx context.Context, id uuid.UUID, statusCode int, body string) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) Insert(ctx context.Context, key *models.IdempotencyKey) (bool, error) {
+	// The unique (user_id, route, key) index decides which of concurrent requests wins
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *gormRepository) Find(ctx context.Context, userID uuid.UUID, route, key string) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	if err := r.db.WithContext(ctx).First(&record, "user_id = ? AND route = ? AND key = ?", userID, route, key).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *gormRepository) Complete(ctx context.Context, id uuid.UUID, statusCode int, body string) error {
+	return r.db.WithContext(ctx).Model(&models.IdempotencyKey{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"status_code": statusCode, "response_body": body}).Error
+}
+
+func (r *gormRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.IdempotencyKey{}, "id = ?", id).Error
+}
+
+func (r *gormRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Delete(&models.IdempotencyKey{}, "expires_at <= ?", now)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,95 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"mini-oms-backend/internal/apperror"
+	"mini-oms-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// abandonedAfter is when an unfinished claim is assumed to belong to a crashed instance.
+// It is far longer than any request may run (see SERVER_WRITE_TIMEOUT_SECONDS).
+const abandonedAfter = 10 * time.Minute
+
+var (
+	ErrKeyReused         = apperror.Conflict("idempotency key was already used for a different request")
+	ErrRequestInProgress = apperror.Conflict("a request with this idempotency key is still being processed")
+)
+
+// Service implements middlewares.IdempotencyStore
+type Service struct {
+	repo Repository
+	ttl  time.Duration
+}
+
+// NewService keeps responses for ttl; retries after that run as new requests
+func NewService(repo Repository, ttl time.Duration) *Service {
+	return &Service{repo: repo, ttl: ttl}
+}
+
+// Begin claims key for a request with the given fingerprint. If an earlier request with the
+// same key has completed, its record is returned for replay (IsCompleted is true). Otherwise
+// the returned record is a new claim which the caller must Complete or Release.
+func (s *Service) Begin(ctx context.Context, userID uuid.UUID, route, key, requestHash string) (*models.IdempotencyKey, error) {
+	// A second attempt is needed when a stale claim was removed or the winner released its claim
+	for attempt := 0; attempt < 2; attempt++ {
+		claim := &models.IdempotencyKey{
+			UserID:      userID,
+			Route:       route,
+			Key:         key,
+			RequestHash: requestHash,
+			ExpiresAt:   time.Now().Add(s.ttl),
+		}
+		inserted, err := s.repo.Insert(ctx, claim)
+		if err != nil {
+			return nil, err
+		}
+		if inserted {
+			return claim, nil
+		}
+
+		existing, err := s.repo.Find(ctx, userID, route, key)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if existing.IsExpired() || (!existing.IsCompleted() && time.Since(existing.CreatedAt) > abandonedAfter) {
+			if err := s.repo.Delete(ctx, existing.ID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if existing.RequestHash != requestHash {
+			return nil, ErrKeyReused
+		}
+		if !existing.IsCompleted() {
+			return nil, ErrRequestInProgress
+		}
+		return existing, nil
+	}
+
+	return nil, ErrRequestInProgress
+}
+
+// Complete stores the response of a claimed request for replay
+func (s *Service) Complete(ctx context.Context, id uuid.UUID, statusCode int, body []byte) error {
+	return s.repo.Complete(ctx, id, statusCode, string(body))
+}
+
+// Release drops a claim so the request can be retried with the same key, e.g. after a server error
+func (s *Service) Release(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// PurgeExpired deletes keys past their retention window and returns how many were deleted
+func (s *Service) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.repo.DeleteExpired(ctx, time.Now())
+}
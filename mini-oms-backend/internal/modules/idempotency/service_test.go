@@ -0,0 +1,99 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"mini-oms-backend/internal/memstore"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const route = "POST /api/orders"
+
+func newTestService() (*Service, *memstore.Store) {
+	store := memstore.New()
+	return NewService(NewMemoryRepository(store), time.Hour), store
+}
+
+func TestBeginReplaysCompletedRequest(t *testing.T) {
+	service, _ := newTestService()
+	ctx := context.Background()
+	userID := uuid.New()
+
+	claim, err := service.Begin(ctx, userID, route, "key-1", "hash-a")
+	if err != nil || claim.IsCompleted() {
+		t.Fatalf("Begin = %+v, %v, want new claim", claim, err)
+	}
+
+	// A retry while the first request runs must not run it again
+	if _, err := service.Begin(ctx, userID, route, "key-1", "hash-a"); !errors.Is(err, ErrRequestInProgress) {
+		t.Fatalf("err = %v, want ErrRequestInProgress", err)
+	}
+
+	if err := service.Complete(ctx, claim.ID, 201, []byte(`{"success":true}`)); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	replay, err := service.Begin(ctx, userID, route, "key-1", "hash-a")
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if !replay.IsCompleted() || replay.StatusCode != 201 || replay.ResponseBody != `{"success":true}` {
+		t.Fatalf("replay = %d %q, want stored 201 response", replay.StatusCode, replay.ResponseBody)
+	}
+}
+
+func TestBeginRejectsKeyReusedForDifferentRequest(t *testing.T) {
+	service, _ := newTestService()
+	ctx := context.Background()
+	userID := uuid.New()
+
+	claim, _ := service.Begin(ctx, userID, route, "key-1", "hash-a")
+	service.Complete(ctx, claim.ID, 201, []byte(`{}`))
+
+	if _, err := service.Begin(ctx, userID, route, "key-1", "hash-b"); !errors.Is(err, ErrKeyReused) {
+		t.Fatalf("err = %v, want ErrKeyReused", err)
+	}
+
+	// Keys are scoped per user and route
+	if claim, err := service.Begin(ctx, uuid.New(), route, "key-1", "hash-b"); err != nil || claim.IsCompleted() {
+		t.Fatalf("other user: %+v, %v, want new claim", claim, err)
+	}
+	if claim, err := service.Begin(ctx, userID, "POST /api/payments", "key-1", "hash-b"); err != nil || claim.IsCompleted() {
+		t.Fatalf("other route: %+v, %v, want new claim", claim, err)
+	}
+}
+
+func TestBeginAfterReleaseOrExpiry(t *testing.T) {
+	service, store := newTestService()
+	ctx := context.Background()
+	userID := uuid.New()
+
+	// A released claim (server error) can be retried
+	claim, _ := service.Begin(ctx, userID, route, "key-1", "hash-a")
+	if err := service.Release(ctx, claim.ID); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	claim, err := service.Begin(ctx, userID, route, "key-1", "hash-a")
+	if err != nil || claim.IsCompleted() {
+		t.Fatalf("after release: %+v, %v, want new claim", claim, err)
+	}
+
+	// An expired response is not replayed, even for a different request
+	service.Complete(ctx, claim.ID, 201, []byte(`{}`))
+	store.Lock()
+	record := store.IdempotencyKeys[claim.ID]
+	record.ExpiresAt = time.Now().Add(-time.Minute)
+	store.IdempotencyKeys[claim.ID] = record
+	store.Unlock()
+
+	claim, err = service.Begin(ctx, userID, route, "key-1", "hash-b")
+	if err != nil || claim.IsCompleted() {
+		t.Fatalf("after expiry: %+v, %v, want new claim", claim, err)
+	}
+	if len(store.IdempotencyKeys) != 1 {
+		t.Errorf("keys = %d, want 1", len(store.IdempotencyKeys))
+	}
+}
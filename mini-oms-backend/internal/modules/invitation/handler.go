@@ -0,0 +1,100 @@
+package invitation
+
+import (
+	"mini-oms-backend/internal/utils"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetAll returns all invitations (invitations:manage)
+func (h *Handler) GetAll(c echo.Context) error {
+	invitations, err := h.service.GetAll(c.Request().Context())
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch invitations")
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Invitations retrieved successfully", invitations)
+}
+
+// Create invites a new admin (invitations:manage)
+// @Summary Create admin invitation
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Param request body CreateInvitationRequest true "Invitation Request"
+// @Success 201 {object} utils.APIResponse
+// @Router /api/admin/invitations [post]
+func (h *Handler) Create(c echo.Context) error {
+	var req CreateInvitationRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	adminID := c.Get("user_id").(uuid.UUID)
+
+	response, err := h.service.Create(c.Request().Context(), adminID, &req)
+	if err != nil {
+		utils.LogError(c.Request().Context(), "InvitationService", adminID.String(), "CreateInvitation", err, "Failed to create invitation")
+		return err
+	}
+
+	utils.LogInfo(c.Request().Context(), "InvitationService", response.Invitation.ID.String(), "CreateInvitation", "Invitation created by admin "+adminID.String())
+	return utils.SuccessResponse(c, http.StatusCreated, "Invitation created successfully", response)
+}
+
+// Revoke revokes a pending invitation (invitations:manage)
+func (h *Handler) Revoke(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid invitation ID")
+	}
+
+	adminID := c.Get("user_id").(uuid.UUID)
+
+	if err := h.service.Revoke(c.Request().Context(), id, adminID); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Invitation revoked successfully", nil)
+}
+
+// Accept creates an admin account from an invitation token
+// @Summary Accept admin invitation
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Param request body AcceptInvitationRequest true "Accept Request"
+// @Success 201 {object} utils.APIResponse
+// @Router /api/invitations/accept [post]
+func (h *Handler) Accept(c echo.Context) error {
+	var req AcceptInvitationRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	user, err := h.service.Accept(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogInfo(c.Request().Context(), "InvitationService", user.ID.String(), "AcceptInvitation", "Admin account created: "+user.Email)
+	return utils.SuccessResponse(c, http.StatusCreated, "Invitation accepted successfully", user)
+}
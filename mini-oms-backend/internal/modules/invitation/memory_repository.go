@@ -0,0 +1,118 @@
+package invitation
+
+import (
+	"context"
+	"mini-oms-backend/internal/memstore"
+	"mini-oms-backend/internal/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// memoryRepository implements Repository on top of memstore, for tests
+type memoryRepository struct {
+	store *memstore.Store
+}
+
+func NewMemoryRepository(store *memstore.Store) Repository {
+	return &memoryRepository{store: store}
+}
+
+func (r *memoryRepository) Transaction(ctx context.Context, fn func(repo Repository) error) error {
+	return r.store.Transaction(ctx, func() error {
+		return fn(r)
+	})
+}
+
+func (r *memoryRepository) FindAll(ctx context.Context) ([]models.Invitation, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	var invitations []models.Invitation
+	for _, invitation := range r.store.Invitations {
+		if inviter, ok := r.store.Users[invitation.InvitedBy]; ok {
+			invitation.Inviter = &inviter
+		}
+		invitations = append(invitations, invitation)
+	}
+	sort.Slice(invitations, func(i, j int) bool { return invitations[i].CreatedAt.After(invitations[j].CreatedAt) })
+	return invitations, nil
+}
+
+func (r *memoryRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Invitation, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	invitation, ok := r.store.Invitations[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &invitation, nil
+}
+
+func (r *memoryRepository) FindByTokenHashForUpdate(ctx context.Context, hash string) (*models.Invitation, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	for _, invitation := range r.store.Invitations {
+		if invitation.TokenHash == hash {
+			return &invitation, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryRepository) Create(ctx context.Context, invitation *models.Invitation) error {
+	if err := invitation.BeforeCreate(nil); err != nil {
+		return err
+	}
+	now := time.Now()
+	invitation.CreatedAt = now
+	invitation.UpdatedAt = now
+	return r.save(invitation)
+}
+
+func (r *memoryRepository) Update(ctx context.Context, invitation *models.Invitation) error {
+	invitation.UpdatedAt = time.Now()
+	return r.save(invitation)
+}
+
+func (r *memoryRepository) save(invitation *models.Invitation) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	row := *invitation
+	row.Inviter = nil
+	r.store.Invitations[invitation.ID] = row
+	return nil
+}
+
+func (r *memoryRepository) HasPendingInvitation(ctx context.Context, email string) bool {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	for _, invitation := range r.store.Invitations {
+		if invitation.Email == email && invitation.IsUsable() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryRepository) EmailRegistered(ctx context.Context, email string) bool {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	_, ok := r.store.FindUserByEmail(email)
+	return ok
+}
+
+func (r *memoryRepository) CreateUser(ctx context.Context, user *models.User) error {
+	return r.store.InsertUser(user)
+}
+
+func (r *memoryRepository) LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error {
+	return r.store.LogAudit(ctx, userID, action, entityName, entityID, details)
+}
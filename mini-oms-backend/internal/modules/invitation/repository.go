@@ -0,0 +1,96 @@
+package invitation
+
+import (
+	"context"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository is the invitation data access used by Service.
+// Methods named ...ForUpdate lock the row and must be called within Transaction.
+type Repository interface {
+	// Transaction runs fn as a unit of work; repo is bound to the transaction
+	Transaction(ctx context.Context, fn func(repo Repository) error) error
+
+	FindAll(ctx context.Context) ([]models.Invitation, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Invitation, error)
+	FindByTokenHashForUpdate(ctx context.Context, hash string) (*models.Invitation, error)
+	Create(ctx context.Context, invitation *models.Invitation) error
+	Update(ctx context.Context, invitation *models.Invitation) error
+	// HasPendingInvitation checks if email already has an open invitation
+	HasPendingInvitation(ctx context.Context, email string) bool
+
+	EmailRegistered(ctx context.Context, email string) bool
+	CreateUser(ctx context.Context, user *models.User) error
+
+	LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error
+}
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) Transaction(ctx context.Context, fn func(repo Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&gormRepository{db: tx})
+	})
+}
+
+func (r *gormRepository) FindAll(ctx context.Context) ([]models.Invitation, error) {
+	var invitations []models.Invitation
+	err := r.db.WithContext(ctx).Preload("Inviter").Order("created_at DESC").Find(&invitations).Error
+	return invitations, err
+}
+
+func (r *gormRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := r.db.WithContext(ctx).First(&invitation, "id = ?", id).Error
+	return &invitation, err
+}
+
+// FindByTokenHashForUpdate finds an invitation and locks the row
+func (r *gormRepository) FindByTokenHashForUpdate(ctx context.Context, hash string) (*models.Invitation, error) {
+	var invitation models.Invitation
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&invitation, "token_hash = ?", hash).Error; err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *gormRepository) Create(ctx context.Context, invitation *models.Invitation) error {
+	return r.db.WithContext(ctx).Create(invitation).Error
+}
+
+func (r *gormRepository) Update(ctx context.Context, invitation *models.Invitation) error {
+	return r.db.WithContext(ctx).Save(invitation).Error
+}
+
+func (r *gormRepository) HasPendingInvitation(ctx context.Context, email string) bool {
+	var count int64
+	r.db.WithContext(ctx).Model(&models.Invitation{}).
+		Where("email = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()", email).
+		Count(&count)
+	return count > 0
+}
+
+func (r *gormRepository) EmailRegistered(ctx context.Context, email string) bool {
+	var count int64
+	r.db.WithContext(ctx).Model(&models.User{}).Where("email = ?", email).Count(&count)
+	return count > 0
+}
+
+func (r *gormRepository) CreateUser(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *gormRepository) LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error {
+	return utils.LogAudit(r.db.WithContext(ctx), userID, action, entityName, entityID, details)
+}
@@ -0,0 +1,168 @@
+package invitation
+
+import (
+	"context"
+	"mini-oms-backend/internal/apperror"
+	"mini-oms-backend/internal/config"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	repo Repository
+	cfg  *config.Config
+}
+
+func NewService(repo Repository, cfg *config.Config) *Service {
+	return &Service{
+		repo: repo,
+		cfg:  cfg,
+	}
+}
+
+var (
+	ErrInvitationNotFound = apperror.NotFound("invitation not found")
+	ErrInvalidToken       = apperror.InvalidInput("invalid invitation token")
+)
+
+// CreateInvitationRequest represents admin invitation request
+type CreateInvitationRequest struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+// AcceptInvitationRequest represents invitation acceptance request
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Name     string `json:"name" validate:"required,max=255"`
+	Password string `json:"password" validate:"required,min=6,max=72"`
+}
+
+// InvitationResponse carries the plain token, which is only returned once
+type InvitationResponse struct {
+	Invitation *models.Invitation `json:"invitation"`
+	Token      string             `json:"token"`
+}
+
+func (s *Service) GetAll(ctx context.Context) ([]models.Invitation, error) {
+	return s.repo.FindAll(ctx)
+}
+
+// Create issues a single-use admin invitation
+func (s *Service) Create(ctx context.Context, adminID uuid.UUID, req *CreateInvitationRequest) (*InvitationResponse, error) {
+	email := strings.TrimSpace(strings.ToLower(req.Email))
+	if email == "" {
+		return nil, apperror.InvalidInput("email is required")
+	}
+
+	if s.repo.EmailRegistered(ctx, email) {
+		return nil, apperror.Conflict("email already registered")
+	}
+
+	if s.repo.HasPendingInvitation(ctx, email) {
+		return nil, apperror.Conflict("an active invitation already exists for this email")
+	}
+
+	token, hash, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &models.Invitation{
+		Email:     email,
+		Role:      "admin",
+		TokenHash: hash,
+		InvitedBy: adminID,
+		ExpiresAt: time.Now().Add(time.Duration(s.cfg.InvitationExpiryHours) * time.Hour),
+	}
+
+	err = s.repo.Transaction(ctx, func(repo Repository) error {
+		if err := repo.Create(ctx, invitation); err != nil {
+			return err
+		}
+		return repo.LogAudit(ctx, adminID, "INVITATION_CREATED", "Invitation", invitation.ID, "Admin invitation sent to "+email)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &InvitationResponse{Invitation: invitation, Token: token}, nil
+}
+
+// Revoke invalidates an invitation that has not been accepted yet
+func (s *Service) Revoke(ctx context.Context, id, adminID uuid.UUID) error {
+	invitation, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return apperror.MapNotFound(err, ErrInvitationNotFound)
+	}
+
+	if invitation.AcceptedAt != nil {
+		return apperror.Conflict("invitation already accepted")
+	}
+	if invitation.RevokedAt != nil {
+		return apperror.Conflict("invitation already revoked")
+	}
+
+	now := time.Now()
+	invitation.RevokedAt = &now
+
+	return s.repo.Transaction(ctx, func(repo Repository) error {
+		if err := repo.Update(ctx, invitation); err != nil {
+			return err
+		}
+		return repo.LogAudit(ctx, adminID, "INVITATION_REVOKED", "Invitation", invitation.ID, "Admin invitation for "+invitation.Email+" revoked")
+	})
+}
+
+// Accept consumes the invitation token and creates the invited admin account
+func (s *Service) Accept(ctx context.Context, req *AcceptInvitationRequest) (*models.User, error) {
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	err = s.repo.Transaction(ctx, func(repo Repository) error {
+		// Lock the invitation so the token can only be consumed once
+		invitation, err := repo.FindByTokenHashForUpdate(ctx, utils.HashToken(req.Token))
+		if err != nil {
+			return apperror.MapNotFound(err, ErrInvalidToken)
+		}
+
+		if !invitation.IsUsable() {
+			return apperror.InvalidInput("invitation is expired or no longer valid")
+		}
+
+		if repo.EmailRegistered(ctx, invitation.Email) {
+			return apperror.Conflict("email already registered")
+		}
+
+		// The invitation link was delivered to this address, so it is verified
+		now := time.Now()
+		user = &models.User{
+			Name:            req.Name,
+			Email:           invitation.Email,
+			Password:        hashedPassword,
+			Role:            invitation.Role,
+			EmailVerifiedAt: &now,
+		}
+		if err := repo.CreateUser(ctx, user); err != nil {
+			return err
+		}
+
+		invitation.AcceptedAt = &now
+		if err := repo.Update(ctx, invitation); err != nil {
+			return err
+		}
+
+		return repo.LogAudit(ctx, user.ID, "INVITATION_ACCEPTED", "Invitation", invitation.ID, "Admin account created for "+user.Email)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,123 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mini-oms-backend/internal/metrics"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/policy"
+	"mini-oms-backend/internal/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxExpiredPerSweep bounds how many orders one sweep cancels so a backlog cannot hold the worker forever
+const maxExpiredPerSweep = 500
+
+// CancelExpiredOrders cancels unpaid orders older than ttl and restocks their items.
+// Each order is canceled in its own transaction; an order that fails is logged and skipped for
+// the rest of the sweep so it cannot block the orders behind it. Returns the number of canceled
+// orders; the error is only set when the orders could not be queried or ctx is done.
+func (s *Service) CancelExpiredOrders(ctx context.Context, ttl time.Duration) (int, error) {
+	cutoff := time.Now().Add(-ttl)
+	canceled := 0
+	var failed []uuid.UUID
+
+	for canceled+len(failed) < maxExpiredPerSweep {
+		found := true
+		var claimed *models.Order
+		err := s.repo.Transaction(ctx, func(repo Repository) error {
+			order, err := repo.FindExpiredUnpaidForUpdate(ctx, cutoff, failed)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					found = false
+					return nil
+				}
+				return err
+			}
+			claimed = order
+
+			details := fmt.Sprintf("no payment received within %s", ttl)
+			if err := cancelLocked(ctx, repo, order, policy.System, details); err != nil {
+				return err
+			}
+
+			utils.LogInfo(ctx, "OrderService", order.ID.String(), "CancelExpiredOrders", "Unpaid order "+order.OrderNumber+" auto-canceled")
+			return nil
+		})
+		if err != nil {
+			if ctx.Err() != nil || claimed == nil {
+				return canceled, err
+			}
+			utils.LogError(ctx, "OrderService", claimed.ID.String(), "CancelExpiredOrders", err, "Auto-cancel of order "+claimed.OrderNumber+" failed, skipped")
+			failed = append(failed, claimed.ID)
+			continue
+		}
+		if !found {
+			break
+		}
+		canceled++
+		metrics.OrdersCanceled.WithLabelValues("system").Inc()
+	}
+
+	if len(failed) > 0 {
+		utils.LogInfo(ctx, "OrderService", "", "CancelExpiredOrders", fmt.Sprintf("%d expired order(s) could not be canceled", len(failed)))
+	}
+	return canceled, nil
+}
+
+// ExpiryWorker periodically auto-cancels unpaid orders
+type ExpiryWorker struct {
+	service  *Service
+	ttl      time.Duration
+	interval time.Duration
+}
+
+func NewExpiryWorker(service *Service, ttl, interval time.Duration) *ExpiryWorker {
+	return &ExpiryWorker{
+		service:  service,
+		ttl:      ttl,
+		interval: interval,
+	}
+}
+
+// Start runs the worker in the background until ctx is canceled.
+// The returned channel is closed once the worker has stopped.
+func (w *ExpiryWorker) Start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		utils.LogInfo(ctx, "OrderExpiryWorker", "", "Start", fmt.Sprintf("Auto-cancel unpaid orders after %s, checking every %s", w.ttl, w.interval))
+
+		for {
+			select {
+			case <-ctx.Done():
+				utils.LogInfo(ctx, "OrderExpiryWorker", "", "Stop", "Worker stopped")
+				return
+			case <-ticker.C:
+				w.sweep(ctx)
+			}
+		}
+	}()
+
+	return done
+}
+
+func (w *ExpiryWorker) sweep(ctx context.Context) {
+	canceled, err := w.service.CancelExpiredOrders(ctx, w.ttl)
+	if err != nil && ctx.Err() == nil {
+		utils.LogError(ctx, "OrderExpiryWorker", "", "Sweep", err, fmt.Sprintf("Sweep aborted after %d cancellation(s)", canceled))
+		return
+	}
+	if canceled > 0 {
+		utils.LogInfo(ctx, "OrderExpiryWorker", "", "Sweep", fmt.Sprintf("Auto-canceled %d unpaid order(s)", canceled))
+	}
+}
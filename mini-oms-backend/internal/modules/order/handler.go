@@ -1,9 +1,13 @@
 package order
 
 import (
+	"errors"
 	"fmt"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/policy"
 	"mini-oms-backend/internal/utils"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -17,39 +21,94 @@ func NewHandler(service *Service) *Handler {
 	return &Handler{service: service}
 }
 
-// GetAll returns all orders (admin) or user's orders
+// GetAll returns all orders (staff with orders:read) or user's orders, paginated
+// Query params: page, limit, status, order_number, user_id (orders:read only),
+// date_from, date_to (YYYY-MM-DD or RFC3339), sort_by, sort_dir
 func (h *Handler) GetAll(c echo.Context) error {
-	userRole := c.Get("user_role").(string)
-	userID := c.Get("user_id").(uuid.UUID)
+	actor := policy.ActorFromContext(c)
+	userID := actor.UserID
+
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	utils.LogInfo(c.Request().Context(), "OrderService", userID.String(), "GetAllOrders", fmt.Sprintf("Fetch requested by role: %s", actor.Role))
+
+	orders, meta, err := h.service.List(c.Request().Context(), actor, filter)
+	if err != nil {
+		utils.LogError(c.Request().Context(), "OrderService", userID.String(), "GetAllOrders", err, "Failed to fetch orders")
+		return utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch orders")
+	}
 
-	utils.LogInfo("OrderService", userID.String(), "GetAllOrders", fmt.Sprintf("Fetch requested by role: %s", userRole))
+	utils.LogInfo(c.Request().Context(), "OrderService", userID.String(), "GetAllOrders", fmt.Sprintf("Retrieved %d of %d orders", len(orders), meta.Total))
+	return utils.SuccessResponseWithMeta(c, http.StatusOK, "Orders retrieved successfully", orders, meta)
+}
+
+// parseOrderFilter builds an OrderFilter from query params
+func parseOrderFilter(c echo.Context) (OrderFilter, error) {
+	filter := OrderFilter{
+		Status:      c.QueryParam("status"),
+		OrderNumber: c.QueryParam("order_number"),
+		SortBy:      c.QueryParam("sort_by"),
+		SortDir:     c.QueryParam("sort_dir"),
+	}
+	filter.Page, filter.Limit = utils.ParsePagination(c.QueryParam("page"), c.QueryParam("limit"))
 
-	var orders []interface{}
+	if filter.Status != "" {
+		switch filter.Status {
+		case models.OrderStatusCreated, models.OrderStatusProcessing, models.OrderStatusShipped, models.OrderStatusCompleted, models.OrderStatusCanceled:
+		default:
+			return filter, errors.New("invalid status filter")
+		}
+	}
+
+	if filter.SortBy != "" {
+		if _, ok := sortableColumns[filter.SortBy]; !ok {
+			return filter, errors.New("invalid sort_by, allowed: created_at, total_amount, order_number, status")
+		}
+	}
+	if filter.SortDir != "" && filter.SortDir != "asc" && filter.SortDir != "desc" {
+		return filter, errors.New("invalid sort_dir, allowed: asc, desc")
+	}
 
-	if userRole == "admin" {
-		// Admin sees all orders
-		ordersData, err := h.service.GetAll()
+	if param := c.QueryParam("user_id"); param != "" {
+		id, err := uuid.Parse(param)
 		if err != nil {
-			utils.LogError("OrderService", userID.String(), "GetAllOrders", err, "Failed to fetch orders")
-			return utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch orders")
+			return filter, errors.New("invalid user_id")
 		}
-		for _, order := range ordersData {
-			orders = append(orders, order)
+		filter.UserID = &id
+	}
+
+	if param := c.QueryParam("date_from"); param != "" {
+		from, _, err := parseDateParam(param)
+		if err != nil {
+			return filter, errors.New("invalid date_from")
 		}
-	} else {
-		// User sees only their orders
-		ordersData, err := h.service.GetByUserID(userID)
+		filter.DateFrom = &from
+	}
+	if param := c.QueryParam("date_to"); param != "" {
+		to, dateOnly, err := parseDateParam(param)
 		if err != nil {
-			utils.LogError("OrderService", userID.String(), "GetAllOrders", err, "Failed to fetch orders")
-			return utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch orders")
+			return filter, errors.New("invalid date_to")
 		}
-		for _, order := range ordersData {
-			orders = append(orders, order)
+		// A plain date includes the whole day
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
 		}
+		filter.DateTo = &to
 	}
 
-	utils.LogInfo("OrderService", userID.String(), "GetAllOrders", fmt.Sprintf("Retrieved %d orders", len(orders)))
-	return utils.SuccessResponse(c, http.StatusOK, "Orders retrieved successfully", orders)
+	return filter, nil
+}
+
+// parseDateParam accepts YYYY-MM-DD or RFC3339 and reports whether it was a plain date
+func parseDateParam(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
 }
 
 // GetByID returns order by ID
@@ -59,21 +118,16 @@ func (h *Handler) GetByID(c echo.Context) error {
 		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid order ID")
 	}
 
-	order, err := h.service.GetByID(id)
-	if err != nil {
-		return utils.ErrorResponse(c, http.StatusNotFound, "Order not found")
-	}
-
-	// Check authorization: user can only see their own orders
-	userRole := c.Get("user_role").(string)
-	userID := c.Get("user_id").(uuid.UUID)
+	// Users can only see their own orders
+	actor := policy.ActorFromContext(c)
+	userID := actor.UserID
 
-	if userRole != "admin" && order.UserID != userID {
-		utils.LogError("OrderService", userID.String(), "GetOrder", nil, "Unauthorized access attempt to order "+id.String())
-		return utils.ErrorResponse(c, http.StatusForbidden, "Access forbidden")
+	order, err := h.service.GetByID(c.Request().Context(), id, actor)
+	if err != nil {
+		return err
 	}
 
-	utils.LogInfo("OrderService", userID.String(), "GetOrder", "Order retrieved: "+id.String())
+	utils.LogInfo(c.Request().Context(), "OrderService", userID.String(), "GetOrder", "Order retrieved: "+id.String())
 	return utils.SuccessResponse(c, http.StatusOK, "Order retrieved successfully", order)
 }
 
@@ -81,22 +135,26 @@ func (h *Handler) GetByID(c echo.Context) error {
 func (h *Handler) Create(c echo.Context) error {
 	var req CreateOrderRequest
 	if err := c.Bind(&req); err != nil {
-		utils.LogError("OrderService", "", "CreateOrder", err, "Failed to bind request")
+		utils.LogError(c.Request().Context(), "OrderService", "", "CreateOrder", err, "Failed to bind request")
 		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
 	}
 
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
 	userID := c.Get("user_id").(uuid.UUID)
 
 	// Log incoming request
-	utils.LogInfo("OrderService", userID.String(), "CreateOrder", fmt.Sprintf("Request received from user %s", userID), fmt.Sprintf("Items count: %d", len(req.Items)))
+	utils.LogInfo(c.Request().Context(), "OrderService", userID.String(), "CreateOrder", fmt.Sprintf("Request received from user %s", userID), fmt.Sprintf("Items count: %d", len(req.Items)))
 
-	response, err := h.service.CreateOrder(userID, &req)
+	response, err := h.service.CreateOrder(c.Request().Context(), userID, &req)
 	if err != nil {
-		utils.LogError("OrderService", userID.String(), "CreateOrder", err, "Failed to create order")
-		return utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		utils.LogError(c.Request().Context(), "OrderService", userID.String(), "CreateOrder", err, "Failed to create order")
+		return err
 	}
 
-	utils.LogInfo("OrderService", response.ID.String(), "CreateOrder", "Order created successfully")
+	utils.LogInfo(c.Request().Context(), "OrderService", response.ID.String(), "CreateOrder", "Order created successfully")
 	return utils.SuccessResponse(c, http.StatusCreated, "Order created successfully", response)
 }
 
@@ -115,20 +173,84 @@ func (h *Handler) Cancel(c echo.Context) error {
 		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid order ID")
 	}
 
-	userID := c.Get("user_id").(uuid.UUID)
-	role := c.Get("user_role").(string)
+	actor := policy.ActorFromContext(c)
 
-	utils.LogInfo("OrderService", orderID.String(), "CancelOrder", fmt.Sprintf("Cancellation requested by user %s (role: %s)", userID, role))
+	utils.LogInfo(c.Request().Context(), "OrderService", orderID.String(), "CancelOrder", fmt.Sprintf("Cancellation requested by user %s (role: %s)", actor.UserID, actor.Role))
 
-	if err := h.service.CancelOrder(orderID, userID, role); err != nil {
-		utils.LogError("OrderService", orderID.String(), "CancelOrder", err, "Cancellation failed")
-		return utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	if err := h.service.CancelOrder(c.Request().Context(), orderID, actor); err != nil {
+		utils.LogError(c.Request().Context(), "OrderService", orderID.String(), "CancelOrder", err, "Cancellation failed")
+		return err
 	}
 
-	utils.LogInfo("OrderService", orderID.String(), "CancelOrder", "Order canceled successfully")
+	utils.LogInfo(c.Request().Context(), "OrderService", orderID.String(), "CancelOrder", "Order canceled successfully")
 	return utils.SuccessResponse(c, http.StatusOK, "Order canceled successfully", nil)
 }
 
+// Ship marks a paid order as shipped (orders:fulfill)
+// @Summary Ship order
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param request body ShipOrderRequest false "Shipping info"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/orders/{id}/ship [post]
+func (h *Handler) Ship(c echo.Context) error {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid order ID")
+	}
+
+	var req ShipOrderRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	actor := policy.ActorFromContext(c)
+
+	utils.LogInfo(c.Request().Context(), "OrderService", orderID.String(), "ShipOrder", fmt.Sprintf("Shipment requested by %s %s", actor.Role, actor.UserID))
+
+	order, err := h.service.ShipOrder(c.Request().Context(), orderID, actor, &req)
+	if err != nil {
+		utils.LogError(c.Request().Context(), "OrderService", orderID.String(), "ShipOrder", err, "Shipment failed")
+		return err
+	}
+
+	utils.LogInfo(c.Request().Context(), "OrderService", orderID.String(), "ShipOrder", "Order shipped successfully")
+	return utils.SuccessResponse(c, http.StatusOK, "Order shipped successfully", order)
+}
+
+// Complete marks a shipped order as completed (orders:fulfill)
+// @Summary Complete order
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/orders/{id}/complete [post]
+func (h *Handler) Complete(c echo.Context) error {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid order ID")
+	}
+
+	actor := policy.ActorFromContext(c)
+
+	utils.LogInfo(c.Request().Context(), "OrderService", orderID.String(), "CompleteOrder", fmt.Sprintf("Completion requested by %s %s", actor.Role, actor.UserID))
+
+	order, err := h.service.CompleteOrder(c.Request().Context(), orderID, actor)
+	if err != nil {
+		utils.LogError(c.Request().Context(), "OrderService", orderID.String(), "CompleteOrder", err, "Completion failed")
+		return err
+	}
+
+	utils.LogInfo(c.Request().Context(), "OrderService", orderID.String(), "CompleteOrder", "Order completed successfully")
+	return utils.SuccessResponse(c, http.StatusOK, "Order completed successfully", order)
+}
+
 // GetStats returns admin dashboard statistics
 func (h *Handler) GetStats(c echo.Context) error {
 	// Log request context (Admin ID usually)
@@ -142,14 +264,14 @@ func (h *Handler) GetStats(c echo.Context) error {
 		userID = u.(uuid.UUID).String()
 	}
 
-	utils.LogInfo("OrderService", userID, "GetStats", "Admin dashboard stats requested by role: "+role)
+	utils.LogInfo(c.Request().Context(), "OrderService", userID, "GetStats", "Admin dashboard stats requested by role: "+role)
 
-	stats, err := h.service.GetStats()
+	stats, err := h.service.GetStats(c.Request().Context())
 	if err != nil {
-		utils.LogError("OrderService", userID, "GetStats", err, "Failed to fetch stats")
-		return utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.LogError(c.Request().Context(), "OrderService", userID, "GetStats", err, "Failed to fetch stats")
+		return err
 	}
 
-	utils.LogInfo("OrderService", userID, "GetStats", fmt.Sprintf("Stats retrieved: Orders=%d, Revenue=%.2f", stats["total_orders"], stats["total_revenue"]))
+	utils.LogInfo(c.Request().Context(), "OrderService", userID, "GetStats", fmt.Sprintf("Stats retrieved: Orders=%d, Revenue=%.2f", stats["total_orders"], stats["total_revenue"]))
 	return utils.SuccessResponse(c, http.StatusOK, "Statistics retrieved", stats)
 }
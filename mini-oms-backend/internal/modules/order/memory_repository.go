@@ -0,0 +1,348 @@
+package order
+
+import (
+	"context"
+	"mini-oms-backend/internal/memstore"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/modules/refund"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// memoryRepository implements Repository on top of memstore, for tests
+type memoryRepository struct {
+	store *memstore.Store
+}
+
+func NewMemoryRepository(store *memstore.Store) Repository {
+	return &memoryRepository{store: store}
+}
+
+func (r *memoryRepository) Transaction(ctx context.Context, fn func(repo Repository) error) error {
+	return r.store.Transaction(ctx, func() error {
+		return fn(r)
+	})
+}
+
+// orderLess compares two orders by one of the sortableColumns
+func orderLess(a, b models.Order, column string) bool {
+	switch column {
+	case "total_amount":
+		return a.TotalAmount < b.TotalAmount
+	case "order_number":
+		return a.OrderNumber < b.OrderNumber
+	case "status":
+		return a.Status < b.Status
+	default:
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+}
+
+func (r *memoryRepository) FindAll(ctx context.Context, filter OrderFilter) ([]models.Order, int64, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	var orders []models.Order
+	for _, order := range r.store.Orders {
+		if order.DeletedAt.Valid {
+			continue
+		}
+		if filter.UserID != nil && order.UserID != *filter.UserID {
+			continue
+		}
+		if filter.Status != "" && order.Status != filter.Status {
+			continue
+		}
+		if filter.OrderNumber != "" && !strings.Contains(strings.ToLower(order.OrderNumber), strings.ToLower(filter.OrderNumber)) {
+			continue
+		}
+		if filter.DateFrom != nil && order.CreatedAt.Before(*filter.DateFrom) {
+			continue
+		}
+		if filter.DateTo != nil && !order.CreatedAt.Before(*filter.DateTo) {
+			continue
+		}
+		orders = append(orders, order)
+	}
+
+	column := filter.SortBy
+	if _, ok := sortableColumns[column]; !ok {
+		column = "created_at"
+	}
+	sort.SliceStable(orders, func(i, j int) bool {
+		if filter.SortDir == "asc" {
+			return orderLess(orders[i], orders[j], column)
+		}
+		return orderLess(orders[j], orders[i], column)
+	})
+
+	total := int64(len(orders))
+	start := min((filter.Page-1)*filter.Limit, len(orders))
+	end := min(start+filter.Limit, len(orders))
+	page := orders[start:end]
+
+	for i := range page {
+		r.attachRelations(&page[i], false)
+	}
+	return page, total, nil
+}
+
+func (r *memoryRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	order, ok := r.store.Orders[id]
+	if !ok || order.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	r.attachRelations(&order, true)
+	return &order, nil
+}
+
+// attachRelations mimics the preloads of the gorm repository (store must be locked)
+func (r *memoryRepository) attachRelations(order *models.Order, withProducts bool) {
+	if user, ok := r.store.Users[order.UserID]; ok {
+		order.User = &user
+	}
+
+	order.OrderItems = r.items(order.ID)
+	if withProducts {
+		for i := range order.OrderItems {
+			if product, ok := r.store.Products[order.OrderItems[i].ProductID]; ok && !product.DeletedAt.Valid {
+				order.OrderItems[i].Product = &product
+			}
+		}
+	}
+
+	order.Payments = nil
+	for _, payment := range r.store.Payments {
+		if payment.OrderID == order.ID {
+			order.Payments = append(order.Payments, payment)
+		}
+	}
+	sort.Slice(order.Payments, func(i, j int) bool { return order.Payments[i].CreatedAt.After(order.Payments[j].CreatedAt) })
+	if len(order.Payments) > 0 {
+		latest := order.Payments[0]
+		order.Payment = &latest
+	}
+}
+
+// items returns the items of an order (store must be locked)
+func (r *memoryRepository) items(orderID uuid.UUID) []models.OrderItem {
+	var items []models.OrderItem
+	for _, item := range r.store.OrderItems {
+		if item.OrderID == orderID {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].CreatedAt.Before(items[j].CreatedAt) })
+	return items
+}
+
+func (r *memoryRepository) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	order, ok := r.store.Orders[id]
+	if !ok || order.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	order.OrderItems = r.items(order.ID)
+	return &order, nil
+}
+
+func (r *memoryRepository) FindExpiredUnpaidForUpdate(ctx context.Context, cutoff time.Time, skip []uuid.UUID) (*models.Order, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	var found *models.Order
+	for _, order := range r.store.Orders {
+		if order.DeletedAt.Valid || order.Status != models.OrderStatusCreated || slices.Contains(skip, order.ID) {
+			continue
+		}
+		start := order.CreatedAt
+		if order.ReopenedAt != nil {
+			start = *order.ReopenedAt
+		}
+		if !start.Before(cutoff) || r.hasActivePayment(order.ID) {
+			continue
+		}
+		if found == nil || order.CreatedAt.Before(found.CreatedAt) {
+			found = &order
+		}
+	}
+	if found == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found.OrderItems = r.items(found.ID)
+	return found, nil
+}
+
+// hasActivePayment checks for a payment that is not failed (store must be locked)
+func (r *memoryRepository) hasActivePayment(orderID uuid.UUID) bool {
+	for _, payment := range r.store.Payments {
+		if payment.OrderID == orderID && payment.Status != models.PaymentStatusFailed {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryRepository) Create(ctx context.Context, order *models.Order) error {
+	if err := order.BeforeCreate(nil); err != nil {
+		return err
+	}
+	now := time.Now()
+	order.CreatedAt = now
+	order.UpdatedAt = now
+
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	for i := range order.OrderItems {
+		item := &order.OrderItems[i]
+		item.OrderID = order.ID
+		if err := item.BeforeCreate(nil); err != nil {
+			return err
+		}
+		item.CreatedAt = now
+		row := *item
+		row.Order = nil
+		row.Product = nil
+		r.store.OrderItems[item.ID] = row
+	}
+
+	row := *order
+	row.User = nil
+	row.OrderItems = nil
+	row.Payment = nil
+	row.Payments = nil
+	r.store.Orders[order.ID] = row
+	return nil
+}
+
+func (r *memoryRepository) SaveOrderStatus(ctx context.Context, order *models.Order, from string) (bool, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	row, ok := r.store.Orders[order.ID]
+	if !ok || row.Status != from {
+		return false, nil
+	}
+	row.Status = order.Status
+	row.ShippedAt = order.ShippedAt
+	row.CompletedAt = order.CompletedAt
+	row.CanceledAt = order.CanceledAt
+	row.ReopenedAt = order.ReopenedAt
+	row.UpdatedAt = time.Now()
+	r.store.Orders[order.ID] = row
+	return true, nil
+}
+
+func (r *memoryRepository) UpdateTrackingNumber(ctx context.Context, id uuid.UUID, trackingNumber string) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	row, ok := r.store.Orders[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	row.TrackingNumber = trackingNumber
+	r.store.Orders[id] = row
+	return nil
+}
+
+func (r *memoryRepository) CountRefunds(ctx context.Context, orderID uuid.UUID) (int64, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	var count int64
+	for _, refund := range r.store.Refunds {
+		if refund.OrderID == orderID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryRepository) FindProductByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Product, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	product, ok := r.store.Products[id]
+	if !ok || product.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &product, nil
+}
+
+func (r *memoryRepository) UpdateProduct(ctx context.Context, product *models.Product) error {
+	product.UpdatedAt = time.Now()
+
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	row := *product
+	row.Category = nil
+	r.store.Products[product.ID] = row
+	return nil
+}
+
+func (r *memoryRepository) FindCapturedPaymentForUpdate(ctx context.Context, orderID uuid.UUID) (*models.Payment, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	for _, payment := range r.store.Payments {
+		if payment.OrderID != orderID {
+			continue
+		}
+		if payment.Status == models.PaymentStatusPaid || payment.Status == models.PaymentStatusPartiallyRefunded {
+			return &payment, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryRepository) Refunds() refund.Repository {
+	return refund.NewMemoryRepository(r.store)
+}
+
+func (r *memoryRepository) LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error {
+	return r.store.LogAudit(ctx, userID, action, entityName, entityID, details)
+}
+
+func (r *memoryRepository) GetStats(ctx context.Context) (*Stats, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	var stats Stats
+	for _, order := range r.store.Orders {
+		if !order.DeletedAt.Valid {
+			stats.TotalOrders++
+		}
+	}
+	for _, payment := range r.store.Payments {
+		for _, status := range capturedPaymentStatuses {
+			if payment.Status == status {
+				stats.GrossRevenue += payment.Amount
+			}
+		}
+		if payment.Status == models.PaymentStatusPending {
+			stats.PendingPayments++
+		}
+	}
+	for _, refund := range r.store.Refunds {
+		switch refund.Status {
+		case models.RefundStatusCompleted:
+			stats.TotalRefunded += refund.Amount
+		case models.RefundStatusPending:
+			stats.PendingRefunds++
+		}
+	}
+	return &stats, nil
+}
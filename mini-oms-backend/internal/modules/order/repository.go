@@ -1,52 +1,300 @@
 package order
 
 import (
+	"context"
 	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/modules/refund"
+	"mini-oms-backend/internal/utils"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-type Repository struct {
+// Repository is the order data access used by Service.
+// Methods named ...ForUpdate lock the row and must be called within Transaction.
+type Repository interface {
+	// Transaction runs fn as a unit of work; repo is bound to the transaction
+	Transaction(ctx context.Context, fn func(repo Repository) error) error
+
+	FindAll(ctx context.Context, filter OrderFilter) ([]models.Order, int64, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Order, error)
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Order, error)
+	// FindExpiredUnpaidForUpdate claims the oldest expired unpaid order whose ID is not in skip
+	FindExpiredUnpaidForUpdate(ctx context.Context, cutoff time.Time, skip []uuid.UUID) (*models.Order, error)
+	Create(ctx context.Context, order *models.Order) error
+	SaveOrderStatus(ctx context.Context, order *models.Order, from string) (bool, error)
+	UpdateTrackingNumber(ctx context.Context, id uuid.UUID, trackingNumber string) error
+	CountRefunds(ctx context.Context, orderID uuid.UUID) (int64, error)
+
+	FindProductByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Product, error)
+	UpdateProduct(ctx context.Context, product *models.Product) error
+
+	FindCapturedPaymentForUpdate(ctx context.Context, orderID uuid.UUID) (*models.Payment, error)
+	// Refunds returns the refund repository sharing this repository's transaction
+	Refunds() refund.Repository
+
+	LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error
+	GetStats(ctx context.Context) (*Stats, error)
+}
+
+// Stats are the aggregates behind the admin dashboard
+type Stats struct {
+	TotalOrders     int64
+	GrossRevenue    float64 // Captured payments, including ones refunded later
+	TotalRefunded   float64 // Completed refunds
+	PendingPayments int64
+	PendingRefunds  int64
+}
+
+// capturedPaymentStatuses are payments whose money was received
+var capturedPaymentStatuses = []models.PaymentStatus{
+	models.PaymentStatusPaid,
+	models.PaymentStatusPartiallyRefunded,
+	models.PaymentStatusRefunded,
+}
+
+type gormRepository struct {
 	db *gorm.DB
 }
 
-func NewRepository(db *gorm.DB) *Repository {
-	return &Repository{db: db}
+func NewRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
 }
 
-func (r *Repository) FindAll() ([]models.Order, error) {
-	var orders []models.Order
-	err := r.db.Preload("User").Preload("OrderItems").Preload("Payment").Find(&orders).Error
-	return orders, err
+func (r *gormRepository) Transaction(ctx context.Context, fn func(repo Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&gormRepository{db: tx})
+	})
 }
 
-func (r *Repository) FindByUserID(userID uuid.UUID) ([]models.Order, error) {
+// loadLatestPayments sets Order.Payment to the latest payment attempt of each order with one
+// query, instead of relying on which row a has-one preload happens to keep
+func (r *gormRepository) loadLatestPayments(ctx context.Context, orders []models.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(orders))
+	for i := range orders {
+		ids[i] = orders[i].ID
+	}
+
+	var payments []models.Payment
+	err := r.db.WithContext(ctx).
+		Raw("SELECT DISTINCT ON (order_id) * FROM payments WHERE order_id IN ? ORDER BY order_id, created_at DESC, id DESC", ids).
+		Scan(&payments).Error
+	if err != nil {
+		return err
+	}
+
+	latest := make(map[uuid.UUID]*models.Payment, len(payments))
+	for i := range payments {
+		latest[payments[i].OrderID] = &payments[i]
+	}
+	for i := range orders {
+		orders[i].Payment = latest[orders[i].ID]
+	}
+	return nil
+}
+
+// OrderFilter holds filters, sorting and pagination for order listing
+type OrderFilter struct {
+	UserID      *uuid.UUID
+	Status      string
+	OrderNumber string
+	DateFrom    *time.Time
+	DateTo      *time.Time
+	SortBy      string // created_at, total_amount, order_number, status
+	SortDir     string // asc, desc
+	Page        int
+	Limit       int
+}
+
+// sortableColumns whitelists columns allowed in ORDER BY
+var sortableColumns = map[string]string{
+	"created_at":   "created_at",
+	"total_amount": "total_amount",
+	"order_number": "order_number",
+	"status":       "status",
+}
+
+// FindAll returns a page of orders matching the filter and the total count
+func (r *gormRepository) FindAll(ctx context.Context, filter OrderFilter) ([]models.Order, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Order{})
+
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.OrderNumber != "" {
+		query = query.Where("order_number ILIKE ?", "%"+filter.OrderNumber+"%")
+	}
+	if filter.DateFrom != nil {
+		query = query.Where("created_at >= ?", *filter.DateFrom)
+	}
+	if filter.DateTo != nil {
+		query = query.Where("created_at < ?", *filter.DateTo)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	column, ok := sortableColumns[filter.SortBy]
+	if !ok {
+		column = "created_at"
+	}
+	direction := "DESC"
+	if filter.SortDir == "asc" {
+		direction = "ASC"
+	}
+
 	var orders []models.Order
-	err := r.db.Preload("OrderItems").Preload("Payment").Where("user_id = ?", userID).Find(&orders).Error
-	return orders, err
+	err := query.Preload("User").Preload("OrderItems").
+		Order(column + " " + direction).Order("id " + direction).
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&orders).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := r.loadLatestPayments(ctx, orders); err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
+
+func (r *gormRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+	var order models.Order
+	err := r.db.WithContext(ctx).Preload("User").Preload("OrderItems.Product").
+		Preload("Payments", func(db *gorm.DB) *gorm.DB { return db.Order("created_at DESC, id DESC") }).
+		First(&order, "id = ?", id).Error
+	if err != nil {
+		return &order, err
+	}
+	// Payments are newest first, so the latest attempt is the first one
+	if len(order.Payments) > 0 {
+		latest := order.Payments[0]
+		order.Payment = &latest
+	}
+	return &order, nil
 }
 
-func (r *Repository) FindByID(id uuid.UUID) (*models.Order, error) {
+// FindByIDForUpdate finds an order with its items and locks the row
+func (r *gormRepository) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Order, error) {
 	var order models.Order
-	err := r.db.Preload("User").Preload("OrderItems.Product").Preload("Payment").First(&order, "id = ?", id).Error
-	return &order, err
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Where("order_id = ?", order.ID).Find(&order.OrderItems).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// FindExpiredUnpaidForUpdate claims one order still in 'created' status, created (or re-opened) before the cutoff,
+// that has no pending or paid payment. Rows locked by another instance are skipped so several
+// workers can sweep concurrently, and so are the orders in skip.
+func (r *gormRepository) FindExpiredUnpaidForUpdate(ctx context.Context, cutoff time.Time, skip []uuid.UUID) (*models.Order, error) {
+	var order models.Order
+	query := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND COALESCE(reopened_at, created_at) < ?", models.OrderStatusCreated, cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM payments WHERE payments.order_id = orders.id AND payments.status <> ?)", models.PaymentStatusFailed)
+	if len(skip) > 0 {
+		query = query.Where("id NOT IN ?", skip)
+	}
+	err := query.Order("created_at ASC").First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Where("order_id = ?", order.ID).Find(&order.OrderItems).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *gormRepository) Create(ctx context.Context, order *models.Order) error {
+	return r.db.WithContext(ctx).Create(order).Error
+}
+
+// SaveOrderStatus writes the status and its timestamps, guarded on the status the order had when read.
+// Returns false when the order was changed in the meantime.
+func (r *gormRepository) SaveOrderStatus(ctx context.Context, order *models.Order, from string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Order{}).Where("id = ? AND status = ?", order.ID, from).Updates(map[string]interface{}{
+		"status":       order.Status,
+		"shipped_at":   order.ShippedAt,
+		"completed_at": order.CompletedAt,
+		"canceled_at":  order.CanceledAt,
+		"reopened_at":  order.ReopenedAt,
+	})
+	return result.RowsAffected > 0, result.Error
 }
 
-func (r *Repository) Create(order *models.Order) error {
-	return r.db.Create(order).Error
+func (r *gormRepository) UpdateTrackingNumber(ctx context.Context, id uuid.UUID, trackingNumber string) error {
+	return r.db.WithContext(ctx).Model(&models.Order{}).Where("id = ?", id).Update("tracking_number", trackingNumber).Error
 }
 
-func (r *Repository) Update(order *models.Order) error {
-	return r.db.Save(order).Error
+func (r *gormRepository) CountRefunds(ctx context.Context, orderID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Refund{}).Where("order_id = ?", orderID).Count(&count).Error
+	return count, err
 }
 
-func (r *Repository) FindProductByID(id uuid.UUID) (*models.Product, error) {
+// FindProductByIDForUpdate finds a product and locks the row
+func (r *gormRepository) FindProductByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Product, error) {
 	var product models.Product
-	err := r.db.First(&product, "id = ?", id).Error
-	return &product, err
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
 }
 
-func (r *Repository) UpdateProduct(product *models.Product) error {
-	return r.db.Save(product).Error
+func (r *gormRepository) UpdateProduct(ctx context.Context, product *models.Product) error {
+	return r.db.WithContext(ctx).Save(product).Error
+}
+
+// FindCapturedPaymentForUpdate finds the paid (or partially refunded) payment of an order and locks the row
+func (r *gormRepository) FindCapturedPaymentForUpdate(ctx context.Context, orderID uuid.UUID) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ? AND status IN ?", orderID, []models.PaymentStatus{models.PaymentStatusPaid, models.PaymentStatusPartiallyRefunded}).
+		First(&payment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *gormRepository) Refunds() refund.Repository {
+	return refund.NewRepository(r.db)
+}
+
+func (r *gormRepository) LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error {
+	return utils.LogAudit(r.db.WithContext(ctx), userID, action, entityName, entityID, details)
+}
+
+func (r *gormRepository) GetStats(ctx context.Context) (*Stats, error) {
+	var stats Stats
+
+	if err := r.db.WithContext(ctx).Model(&models.Order{}).Count(&stats.TotalOrders).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Model(&models.Payment{}).Where("status IN ?", capturedPaymentStatuses).Select("COALESCE(SUM(amount), 0)").Scan(&stats.GrossRevenue).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Model(&models.Refund{}).Where("status = ?", models.RefundStatusCompleted).Select("COALESCE(SUM(amount), 0)").Scan(&stats.TotalRefunded).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Model(&models.Payment{}).Where("status = ?", models.PaymentStatusPending).Count(&stats.PendingPayments).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Model(&models.Refund{}).Where("status = ?", models.RefundStatusPending).Count(&stats.PendingRefunds).Error; err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
 }
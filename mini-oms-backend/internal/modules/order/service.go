@@ -1,10 +1,16 @@
 package order
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"math/rand"
+	"mini-oms-backend/internal/apperror"
+	"mini-oms-backend/internal/metrics"
 	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/modules/refund"
+	"mini-oms-backend/internal/policy"
 	"mini-oms-backend/internal/utils"
 	"time"
 
@@ -13,227 +19,343 @@ import (
 )
 
 type Service struct {
-	repo *Repository
-	db   *gorm.DB
+	repo Repository
 }
 
-func NewService(repo *Repository, db *gorm.DB) *Service {
-	return &Service{
-		repo: repo,
-		db:   db,
-	}
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
 }
 
 type OrderItemRequest struct {
-	ProductID uuid.UUID `json:"product_id"`
-	Quantity  int       `json:"quantity"`
+	ProductID uuid.UUID `json:"product_id" validate:"required"`
+	Quantity  int       `json:"quantity" validate:"gt=0,lte=1000"`
 }
 
+// CreateOrderRequest lists each product once; quantities of the same product must be combined
 type CreateOrderRequest struct {
-	Items []OrderItemRequest `json:"items"`
-	Notes string             `json:"notes"`
+	Items []OrderItemRequest `json:"items" validate:"required,min=1,max=100,unique=ProductID,dive"`
+	Notes string             `json:"notes" validate:"max=1000"`
 }
 
-func (s *Service) GetAll() ([]models.Order, error) {
-	return s.repo.FindAll()
-}
+// List returns a page of orders. Callers without orders:read are always scoped to their own orders.
+func (s *Service) List(ctx context.Context, actor policy.Actor, filter OrderFilter) ([]models.Order, utils.PaginationMeta, error) {
+	if !actor.Can(policy.PermOrdersRead) {
+		filter.UserID = &actor.UserID
+	}
+
+	orders, total, err := s.repo.FindAll(ctx, filter)
+	if err != nil {
+		return nil, utils.PaginationMeta{}, err
+	}
 
-func (s *Service) GetByUserID(userID uuid.UUID) ([]models.Order, error) {
-	return s.repo.FindByUserID(userID)
+	return orders, utils.NewPaginationMeta(filter.Page, filter.Limit, total), nil
 }
 
-func (s *Service) GetByID(id uuid.UUID) (*models.Order, error) {
-	return s.repo.FindByID(id)
+// GetByID returns an order the caller owns; staff with orders:read may read any order
+func (s *Service) GetByID(ctx context.Context, id uuid.UUID, actor policy.Actor) (*models.Order, error) {
+	return Authorize(ctx, s.repo, id, actor, policy.PermOrdersRead, "view")
 }
 
-func (s *Service) CreateOrder(userID uuid.UUID, req *CreateOrderRequest) (*models.Order, error) {
-	if len(req.Items) == 0 {
-		return nil, errors.New("order must have at least one item")
+// Authorize loads an order and checks that actor owns it or holds permission. Orders of
+// other users are reported as ErrOrderNotFound and the attempt is audited, so repo must
+// not be bound to a transaction that will roll back.
+func Authorize(ctx context.Context, repo Repository, orderID uuid.UUID, actor policy.Actor, permission, operation string) (*models.Order, error) {
+	order, err := repo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, apperror.MapNotFound(err, ErrOrderNotFound)
 	}
 
-	// Start database transaction
-	tx := s.db.Begin()
-	if tx.Error != nil {
-		return nil, tx.Error
+	resource := policy.Resource{Entity: "Order", ID: order.ID, OwnerID: order.UserID}
+	if err := policy.OwnerOrPermitted(ctx, repo, actor, resource, permission, operation, ErrOrderNotFound); err != nil {
+		return nil, err
 	}
+	return order, nil
+}
 
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	var totalAmount float64
-	var orderItems []models.OrderItem
+func (s *Service) CreateOrder(ctx context.Context, userID uuid.UUID, req *CreateOrderRequest) (*models.Order, error) {
+	if len(req.Items) == 0 {
+		return nil, apperror.InvalidInput("order must have at least one item")
+	}
 
-	// Process each order item
+	// Handlers validate the request too; this guards other callers since a negative
+	// quantity would add stock instead of reserving it
+	seen := make(map[uuid.UUID]bool, len(req.Items))
 	for _, item := range req.Items {
-		// Get product with ROW LOCK to prevent race conditions
-		// We use s.repo.FindProductByIDWithLock (we need to expose this via interface or access repo directly)
-		// Since product repo is separate, we'll assume we can call it differently or query directly via tx
-		var product models.Product
-		if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&product, "id = ?", item.ProductID).Error; err != nil {
-			tx.Rollback()
-			return nil, errors.New("product not found")
+		if item.Quantity <= 0 {
+			return nil, apperror.InvalidInput("item quantity must be greater than 0")
 		}
-
-		// Check stock
-		if !product.IsInStock(item.Quantity) {
-			tx.Rollback()
-			return nil, errors.New("insufficient stock for product: " + product.Name)
+		if seen[item.ProductID] {
+			return nil, apperror.InvalidInput("each product may only appear once per order")
 		}
+		seen[item.ProductID] = true
+	}
 
-		// Calculate subtotal
-		subtotal := product.Price * float64(item.Quantity)
-		totalAmount += subtotal
+	var order *models.Order
+	err := s.repo.Transaction(ctx, func(repo Repository) error {
+		var totalAmount float64
+		var orderItems []models.OrderItem
+
+		// Process each order item
+		for _, item := range req.Items {
+			// Get product with ROW LOCK to prevent race conditions
+			product, err := repo.FindProductByIDForUpdate(ctx, item.ProductID)
+			if err != nil {
+				return apperror.MapNotFound(err, ErrProductNotFound)
+			}
+
+			// Check stock
+			if !product.IsInStock(item.Quantity) {
+				return fmt.Errorf("%w: %s", ErrInsufficientStock, product.Name)
+			}
+
+			// Calculate subtotal
+			subtotal := product.Price * float64(item.Quantity)
+			totalAmount += subtotal
+
+			// Create order item with product snapshot
+			orderItem := models.OrderItem{
+				ProductID:    product.ID,
+				ProductName:  product.Name,
+				ProductPrice: product.Price,
+				Quantity:     item.Quantity,
+				Subtotal:     subtotal,
+			}
+			orderItems = append(orderItems, orderItem)
+
+			// Reduce stock
+			if err := product.ReduceStock(item.Quantity); err != nil {
+				return err
+			}
+
+			// Update product stock in transaction
+			if err := repo.UpdateProduct(ctx, product); err != nil {
+				return err
+			}
+		}
 
-		// Create order item with product snapshot
-		orderItem := models.OrderItem{
-			ProductID:    product.ID,
-			ProductName:  product.Name,
-			ProductPrice: product.Price,
-			Quantity:     item.Quantity,
-			Subtotal:     subtotal,
+		// Generate Order Number: ORD-YYYYMMDD-HHMMSS-XXXX (Collision resistant)
+		// We use local RNG to avoid global lock contention and seed it with time
+		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+		orderNumber := fmt.Sprintf("ORD-%s-%04d", time.Now().Format("20060102-150405"), rng.Intn(10000))
+
+		// Create order
+		order = &models.Order{
+			UserID:      userID,
+			OrderNumber: orderNumber,
+			TotalAmount: totalAmount,
+			Status:      models.OrderStatusCreated,
+			Notes:       req.Notes,
+			OrderItems:  orderItems,
 		}
-		orderItems = append(orderItems, orderItem)
 
-		// Reduce stock
-		if err := product.ReduceStock(item.Quantity); err != nil {
-			tx.Rollback()
-			return nil, err
+		if err := repo.Create(ctx, order); err != nil {
+			return err
 		}
 
-		// Update product stock in transaction
-		if err := tx.Save(product).Error; err != nil {
-			tx.Rollback()
-			return nil, err
+		// Log Audit
+		return repo.LogAudit(ctx, userID, "ORDER_CREATED", "Order", order.ID, fmt.Sprintf("Order created with %d items", len(orderItems)))
+	})
+	if err != nil {
+		if errors.Is(err, ErrInsufficientStock) {
+			metrics.OutOfStockRejections.WithLabelValues("create").Inc()
 		}
+		return nil, err
 	}
+	metrics.OrdersCreated.Inc()
 
-	// Generate Order Number: ORD-YYYYMMDD-HHMMSS-XXXX (Collision resistant)
-	// We use local RNG to avoid global lock contention and seed it with time
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-	orderNumber := fmt.Sprintf("ORD-%s-%04d", time.Now().Format("20060102-150405"), rng.Intn(10000))
-
-	// Create order
-	order := &models.Order{
-		UserID:      userID,
-		OrderNumber: orderNumber,
-		TotalAmount: totalAmount,
-		Status:      models.OrderStatusCreated,
-		Notes:       req.Notes,
-		OrderItems:  orderItems,
-	}
+	// Reload order with relations
+	return s.repo.FindByID(ctx, order.ID)
+}
 
-	if err := tx.Create(order).Error; err != nil {
-		tx.Rollback()
-		return nil, err
-	}
+var (
+	ErrOrderNotFound     = apperror.NotFound("order not found")
+	ErrProductNotFound   = apperror.NotFound("product not found")
+	ErrInsufficientStock = apperror.InsufficientStock("insufficient stock for product")
+)
 
-	// Log Audit
-	if err := utils.LogAudit(tx, userID, "ORDER_CREATED", "Order", order.ID, fmt.Sprintf("Order created with %d items", len(orderItems))); err != nil {
-		tx.Rollback()
-		return nil, err
-	}
+type ShipOrderRequest struct {
+	TrackingNumber string `json:"tracking_number" validate:"max=100"`
+}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		return nil, err
+func (s *Service) CancelOrder(ctx context.Context, orderID uuid.UUID, actor policy.Actor) error {
+	// Only the owner or staff with orders:cancel can cancel; checked before the transaction so denials stay in the audit log
+	if _, err := Authorize(ctx, s.repo, orderID, actor, policy.PermOrdersCancel, "cancel"); err != nil {
+		return err
 	}
 
-	// Reload order with relations
-	return s.repo.FindByID(order.ID)
-}
+	err := s.repo.Transaction(ctx, func(repo Repository) error {
+		// Find order with LOCK so status checks and restock see a consistent row
+		order, err := repo.FindByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return apperror.MapNotFound(err, ErrOrderNotFound)
+		}
 
-func (s *Service) CancelOrder(orderID, userID uuid.UUID, role string) error {
-	// Find order
-	order, err := s.repo.FindByID(orderID)
+		return cancelLocked(ctx, repo, order, actor, "")
+	})
 	if err != nil {
-		return errors.New("order not found")
+		return err
 	}
 
-	// Permission check (only owner or admin can cancel)
-	if role != "admin" && order.UserID != userID {
-		return errors.New("unauthorized")
-	}
+	metrics.OrdersCanceled.WithLabelValues(actor.Role).Inc()
+	return nil
+}
 
-	// Check status (can only cancel if created or processing)
-	// If already paid (processing), usually needs refund logic, but for now we just cancel
-	if order.Status == "completed" || order.Status == "canceled" {
-		return errors.New("cannot cancel completed or already canceled order")
-	}
+// cancelLocked cancels an order the caller has locked within repo's transaction: it moves the status,
+// requests a refund for paid orders and restores stock
+func cancelLocked(ctx context.Context, repo Repository, order *models.Order, actor policy.Actor, details string) error {
+	wasPaid := order.Status == models.OrderStatusProcessing
 
-	// Start transaction
-	tx := s.db.Begin()
-	if tx.Error != nil {
-		return tx.Error
+	// 1. Update Order Status (state machine: owners may cancel unpaid orders, staff with orders:cancel also paid ones)
+	if err := ApplyTransition(ctx, repo, order, models.OrderStatusCanceled, actor, details); err != nil {
+		return err
 	}
 
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	// 2. Refund the captured payment of a paid order
+	if wasPaid {
+		payment, err := repo.FindCapturedPaymentForUpdate(ctx, order.ID)
+		if err == nil {
+			if _, err := refund.RequestRefund(ctx, repo.Refunds(), payment, 0, "Order "+order.OrderNumber+" canceled", actor.UserID); err != nil {
+				return err
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
 		}
-	}()
-
-	// 1. Update Order Status
-	order.Status = "canceled"
-	if err := tx.Save(order).Error; err != nil {
-		tx.Rollback()
-		return err
 	}
 
-	// 2. Restore Stock
+	// 3. Restore Stock
 	for _, item := range order.OrderItems {
 		// Find product with LOCK
-		var product models.Product
-		if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&product, "id = ?", item.ProductID).Error; err != nil {
-			// If product not found/deleted, we skip restocking and continue
+		product, err := repo.FindProductByIDForUpdate(ctx, item.ProductID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Product was deleted, nothing to restock
 			continue
 		}
+		if err != nil {
+			return err
+		}
 
 		product.IncreaseStock(item.Quantity)
 
-		if err := tx.Save(&product).Error; err != nil {
-			tx.Rollback()
+		if err := repo.UpdateProduct(ctx, product); err != nil {
 			return err
 		}
 	}
 
-	// Log Audit
-	if err := utils.LogAudit(tx, userID, "ORDER_CANCELED", "Order", order.ID, "Order canceled by user/admin"); err != nil {
-		tx.Rollback()
-		return err
+	return nil
+}
+
+// ReopenOrder moves a canceled, never-paid order back to 'created' and reserves its stock again (orders:cancel)
+func (s *Service) ReopenOrder(ctx context.Context, orderID uuid.UUID, actor policy.Actor) (*models.Order, error) {
+	err := s.repo.Transaction(ctx, func(repo Repository) error {
+		order, err := repo.FindByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return apperror.MapNotFound(err, ErrOrderNotFound)
+		}
+
+		// Paid orders were refunded on cancel; re-opening them would need the money back first
+		refunds, err := repo.CountRefunds(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		if refunds > 0 {
+			return apperror.Conflict("order has refunds and cannot be re-opened")
+		}
+
+		if err := ApplyTransition(ctx, repo, order, models.OrderStatusCreated, actor, ""); err != nil {
+			return err
+		}
+
+		// Reserve stock again
+		for _, item := range order.OrderItems {
+			product, err := repo.FindProductByIDForUpdate(ctx, item.ProductID)
+			if err != nil {
+				return apperror.MapNotFound(err, apperror.NotFound("product not found: "+item.ProductName))
+			}
+
+			if err := product.ReduceStock(item.Quantity); err != nil {
+				return fmt.Errorf("%w: %s", ErrInsufficientStock, product.Name)
+			}
+
+			if err := repo.UpdateProduct(ctx, product); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrInsufficientStock) {
+			metrics.OutOfStockRejections.WithLabelValues("reopen").Inc()
+		}
+		return nil, err
+	}
+
+	return s.repo.FindByID(ctx, orderID)
+}
+
+// ShipOrder marks a paid order as shipped (orders:fulfill)
+func (s *Service) ShipOrder(ctx context.Context, orderID uuid.UUID, actor policy.Actor, req *ShipOrderRequest) (*models.Order, error) {
+	err := s.repo.Transaction(ctx, func(repo Repository) error {
+		order, err := repo.FindByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return apperror.MapNotFound(err, ErrOrderNotFound)
+		}
+
+		details := ""
+		if req.TrackingNumber != "" {
+			details = "tracking number " + req.TrackingNumber
+			if err := repo.UpdateTrackingNumber(ctx, order.ID, req.TrackingNumber); err != nil {
+				return err
+			}
+		}
+
+		return ApplyTransition(ctx, repo, order, models.OrderStatusShipped, actor, details)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return tx.Commit().Error
+	return s.repo.FindByID(ctx, orderID)
 }
 
-func (s *Service) GetStats() (map[string]interface{}, error) {
-	var totalOrders int64
-	var totalRevenue float64
-	var pendingPayments int64
+// CompleteOrder marks a shipped order as completed (orders:fulfill)
+func (s *Service) CompleteOrder(ctx context.Context, orderID uuid.UUID, actor policy.Actor) (*models.Order, error) {
+	err := s.repo.Transaction(ctx, func(repo Repository) error {
+		order, err := repo.FindByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return apperror.MapNotFound(err, ErrOrderNotFound)
+		}
+
+		return ApplyTransition(ctx, repo, order, models.OrderStatusCompleted, actor, "")
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	// Total Orders
-	s.db.Model(&models.Order{}).Count(&totalOrders)
+	return s.repo.FindByID(ctx, orderID)
+}
 
-	// Total Revenue (Sum of Orders with status processing, completed, paid, or success)
-	// Adding 'paid' and 'success' for backward compatibility or if data manually seeded
-	statuses := []string{
-		models.OrderStatusProcessing,
-		models.OrderStatusCompleted,
-		"paid",
-		"success",
+func (s *Service) GetStats(ctx context.Context) (map[string]interface{}, error) {
+	stats, err := s.repo.GetStats(ctx)
+	if err != nil {
+		return nil, err
 	}
-	s.db.Model(&models.Order{}).Where("status IN ?", statuses).Select("COALESCE(SUM(total_amount), 0)").Scan(&totalRevenue)
 
-	// Pending Payments (Status Created + Payment Pending)
-	s.db.Model(&models.Payment{}).Where("status = ?", "pending").Count(&pendingPayments)
+	// Refunds already paid out are netted from revenue
+	totalRevenue := stats.GrossRevenue - stats.TotalRefunded
 
-	fmt.Printf("DEBUG STATS: Orders=%d, Revenue=%.2f, Pending=%d\n", totalOrders, totalRevenue, pendingPayments)
+	slog.DebugContext(ctx, "Order stats computed",
+		"total_orders", stats.TotalOrders,
+		"total_revenue", totalRevenue,
+		"pending_payments", stats.PendingPayments,
+	)
 
 	return map[string]interface{}{
-		"total_orders":     totalOrders,
+		"total_orders":     stats.TotalOrders,
 		"total_revenue":    totalRevenue,
-		"pending_payments": pendingPayments,
+		"gross_revenue":    stats.GrossRevenue,
+		"total_refunded":   stats.TotalRefunded,
+		"pending_payments": stats.PendingPayments,
+		"pending_refunds":  stats.PendingRefunds,
 	}, nil
 }
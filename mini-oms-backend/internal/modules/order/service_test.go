@@ -0,0 +1,456 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"mini-oms-backend/internal/memstore"
+	"mini-oms-backend/internal/metrics"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/policy"
+	"mini-oms-backend/internal/utils"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func newTestService() (*Service, *memstore.Store) {
+	store := memstore.New()
+	return NewService(NewMemoryRepository(store)), store
+}
+
+func seedProduct(store *memstore.Store, name string, price float64, stock int) uuid.UUID {
+	product := models.Product{ID: uuid.New(), Name: name, Price: price, Stock: stock, CreatedAt: time.Now()}
+	store.Products[product.ID] = product
+	return product.ID
+}
+
+func stockOf(store *memstore.Store, productID uuid.UUID) int {
+	return store.Products[productID].Stock
+}
+
+func createOrder(t *testing.T, service *Service, userID uuid.UUID, items ...OrderItemRequest) *models.Order {
+	t.Helper()
+	order, err := service.CreateOrder(context.Background(), userID, &CreateOrderRequest{Items: items})
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	return order
+}
+
+func TestCreateOrderReservesStock(t *testing.T) {
+	service, store := newTestService()
+	userID := uuid.New()
+	keyboard := seedProduct(store, "Keyboard", 150000, 10)
+	mouse := seedProduct(store, "Mouse", 50000, 5)
+
+	order := createOrder(t, service, userID,
+		OrderItemRequest{ProductID: keyboard, Quantity: 2},
+		OrderItemRequest{ProductID: mouse, Quantity: 1},
+	)
+
+	if order.Status != models.OrderStatusCreated {
+		t.Errorf("status = %s, want %s", order.Status, models.OrderStatusCreated)
+	}
+	if order.TotalAmount != 350000 {
+		t.Errorf("total = %.2f, want 350000", order.TotalAmount)
+	}
+	if len(order.OrderItems) != 2 {
+		t.Fatalf("items = %d, want 2", len(order.OrderItems))
+	}
+	if got := stockOf(store, keyboard); got != 8 {
+		t.Errorf("keyboard stock = %d, want 8", got)
+	}
+	if got := stockOf(store, mouse); got != 4 {
+		t.Errorf("mouse stock = %d, want 4", got)
+	}
+	if actions := store.AuditActions(order.ID); !slices.Equal(actions, []string{"ORDER_CREATED"}) {
+		t.Errorf("audit = %v, want [ORDER_CREATED]", actions)
+	}
+}
+
+func TestCreateOrderAuditCarriesRequestID(t *testing.T) {
+	service, store := newTestService()
+	keyboard := seedProduct(store, "Keyboard", 150000, 10)
+
+	ctx := utils.WithRequestID(context.Background(), "req-123")
+	order, err := service.CreateOrder(ctx, uuid.New(), &CreateOrderRequest{Items: []OrderItemRequest{{ProductID: keyboard, Quantity: 1}}})
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+
+	for _, entry := range store.AuditLogs {
+		if entry.EntityID == order.ID && entry.RequestID != "req-123" {
+			t.Errorf("audit %s request_id = %q, want req-123", entry.Action, entry.RequestID)
+		}
+	}
+}
+
+func TestCreateOrderRequiresItems(t *testing.T) {
+	service, _ := newTestService()
+
+	if _, err := service.CreateOrder(context.Background(), uuid.New(), &CreateOrderRequest{}); err == nil {
+		t.Fatal("expected error for empty order")
+	}
+}
+
+func TestCreateOrderRejectsInvalidItems(t *testing.T) {
+	service, store := newTestService()
+	keyboard := seedProduct(store, "Keyboard", 150000, 10)
+
+	cases := map[string][]OrderItemRequest{
+		"zero quantity":     {{ProductID: keyboard, Quantity: 0}},
+		"negative quantity": {{ProductID: keyboard, Quantity: -3}},
+		"duplicate product": {{ProductID: keyboard, Quantity: 1}, {ProductID: keyboard, Quantity: 2}},
+	}
+	for name, items := range cases {
+		if _, err := service.CreateOrder(context.Background(), uuid.New(), &CreateOrderRequest{Items: items}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	if got := stockOf(store, keyboard); got != 10 {
+		t.Errorf("stock = %d, want 10", got)
+	}
+}
+
+func TestCreateOrderInsufficientStockRollsBack(t *testing.T) {
+	service, store := newTestService()
+	keyboard := seedProduct(store, "Keyboard", 150000, 10)
+	mouse := seedProduct(store, "Mouse", 50000, 1)
+	rejections := testutil.ToFloat64(metrics.OutOfStockRejections.WithLabelValues("create"))
+
+	_, err := service.CreateOrder(context.Background(), uuid.New(), &CreateOrderRequest{Items: []OrderItemRequest{
+		{ProductID: keyboard, Quantity: 2},
+		{ProductID: mouse, Quantity: 3},
+	}})
+	if !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("err = %v, want ErrInsufficientStock", err)
+	}
+	if got := testutil.ToFloat64(metrics.OutOfStockRejections.WithLabelValues("create")) - rejections; got != 1 {
+		t.Errorf("out of stock rejections += %v, want 1", got)
+	}
+
+	// The keyboard reservation made before the failure must be rolled back
+	if got := stockOf(store, keyboard); got != 10 {
+		t.Errorf("keyboard stock = %d, want 10", got)
+	}
+	if len(store.Orders) != 0 || len(store.OrderItems) != 0 {
+		t.Errorf("orders = %d, items = %d, want none", len(store.Orders), len(store.OrderItems))
+	}
+}
+
+func TestCreateOrderUnknownProduct(t *testing.T) {
+	service, _ := newTestService()
+
+	_, err := service.CreateOrder(context.Background(), uuid.New(), &CreateOrderRequest{Items: []OrderItemRequest{{ProductID: uuid.New(), Quantity: 1}}})
+	if err == nil || err.Error() != "product not found" {
+		t.Fatalf("err = %v, want product not found", err)
+	}
+}
+
+func TestCreateOrderCanceledContext(t *testing.T) {
+	service, store := newTestService()
+	keyboard := seedProduct(store, "Keyboard", 150000, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := service.CreateOrder(ctx, uuid.New(), &CreateOrderRequest{Items: []OrderItemRequest{{ProductID: keyboard, Quantity: 2}}})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if got := stockOf(store, keyboard); got != 10 {
+		t.Errorf("stock = %d, want 10", got)
+	}
+	if len(store.Orders) != 0 {
+		t.Errorf("orders = %d, want none", len(store.Orders))
+	}
+}
+
+func TestCancelOrderRestoresStock(t *testing.T) {
+	service, store := newTestService()
+	userID := uuid.New()
+	keyboard := seedProduct(store, "Keyboard", 150000, 10)
+	order := createOrder(t, service, userID, OrderItemRequest{ProductID: keyboard, Quantity: 4})
+
+	if err := service.CancelOrder(context.Background(), order.ID, policy.Customer(userID)); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+
+	canceled := store.Orders[order.ID]
+	if canceled.Status != models.OrderStatusCanceled {
+		t.Errorf("status = %s, want %s", canceled.Status, models.OrderStatusCanceled)
+	}
+	if canceled.CanceledAt == nil {
+		t.Error("canceled_at not set")
+	}
+	if got := stockOf(store, keyboard); got != 10 {
+		t.Errorf("stock = %d, want 10", got)
+	}
+	if actions := store.AuditActions(order.ID); !slices.Contains(actions, "ORDER_CANCELED") {
+		t.Errorf("audit = %v, want ORDER_CANCELED", actions)
+	}
+}
+
+func TestCancelOrderOfAnotherUser(t *testing.T) {
+	service, store := newTestService()
+	keyboard := seedProduct(store, "Keyboard", 150000, 10)
+	order := createOrder(t, service, uuid.New(), OrderItemRequest{ProductID: keyboard, Quantity: 1})
+
+	// Other users' orders are reported as missing, and the attempt is audited
+	err := service.CancelOrder(context.Background(), order.ID, policy.Customer(uuid.New()))
+	if !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("err = %v, want ErrOrderNotFound", err)
+	}
+	if actions := store.AuditActions(order.ID); !slices.Contains(actions, policy.ActionAccessDenied) {
+		t.Errorf("audit = %v, want %s", actions, policy.ActionAccessDenied)
+	}
+	if got := store.Orders[order.ID].Status; got != models.OrderStatusCreated {
+		t.Errorf("status = %s, want %s", got, models.OrderStatusCreated)
+	}
+	if got := stockOf(store, keyboard); got != 9 {
+		t.Errorf("stock = %d, want 9", got)
+	}
+}
+
+func TestGetByIDOwnerOrStaff(t *testing.T) {
+	service, store := newTestService()
+	keyboard := seedProduct(store, "Keyboard", 150000, 10)
+	ownerID := uuid.New()
+	order := createOrder(t, service, ownerID, OrderItemRequest{ProductID: keyboard, Quantity: 1})
+
+	if _, err := service.GetByID(context.Background(), order.ID, policy.Customer(ownerID)); err != nil {
+		t.Fatalf("owner: %v", err)
+	}
+	if _, err := service.GetByID(context.Background(), order.ID, policy.Admin(uuid.New())); err != nil {
+		t.Fatalf("admin: %v", err)
+	}
+	fulfillment := policy.Actor{UserID: uuid.New(), Role: "fulfillment", Permissions: []string{policy.PermOrdersRead, policy.PermOrdersFulfill}}
+	if _, err := service.GetByID(context.Background(), order.ID, fulfillment); err != nil {
+		t.Fatalf("orders:read: %v", err)
+	}
+
+	// Existing and missing orders of other users look the same
+	if _, err := service.GetByID(context.Background(), order.ID, policy.Customer(uuid.New())); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("other user: err = %v, want ErrOrderNotFound", err)
+	}
+	if _, err := service.GetByID(context.Background(), uuid.New(), policy.Customer(ownerID)); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("missing: err = %v, want ErrOrderNotFound", err)
+	}
+}
+
+func TestCancelPaidOrderRequiresPermission(t *testing.T) {
+	service, store := newTestService()
+	keyboard := seedProduct(store, "Keyboard", 150000, 10)
+	order := createOrder(t, service, uuid.New(), OrderItemRequest{ProductID: keyboard, Quantity: 1})
+
+	paid := store.Orders[order.ID]
+	paid.Status = models.OrderStatusProcessing
+	store.Orders[order.ID] = paid
+
+	// Reading all orders does not allow canceling them
+	fulfillment := policy.Actor{UserID: uuid.New(), Role: "fulfillment", Permissions: []string{policy.PermOrdersRead, policy.PermOrdersFulfill}}
+	if err := service.CancelOrder(context.Background(), order.ID, fulfillment); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("fulfillment: err = %v, want ErrOrderNotFound", err)
+	}
+
+	support := policy.Actor{UserID: uuid.New(), Role: "support", Permissions: []string{policy.PermOrdersRead, policy.PermOrdersCancel}}
+	if err := service.CancelOrder(context.Background(), order.ID, support); err != nil {
+		t.Fatalf("support: %v", err)
+	}
+	if got := store.Orders[order.ID].Status; got != models.OrderStatusCanceled {
+		t.Errorf("status = %s, want %s", got, models.OrderStatusCanceled)
+	}
+}
+
+func TestCancelOrderTwiceDoesNotRestockAgain(t *testing.T) {
+	service, store := newTestService()
+	userID := uuid.New()
+	keyboard := seedProduct(store, "Keyboard", 150000, 10)
+	order := createOrder(t, service, userID, OrderItemRequest{ProductID: keyboard, Quantity: 3})
+
+	if err := service.CancelOrder(context.Background(), order.ID, policy.Customer(userID)); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+	err := service.CancelOrder(context.Background(), order.ID, policy.Customer(userID))
+	if !IsTransitionError(err) {
+		t.Fatalf("err = %v, want transition error", err)
+	}
+	if got := stockOf(store, keyboard); got != 10 {
+		t.Errorf("stock = %d, want 10", got)
+	}
+}
+
+func TestCancelPaidOrderRequestsRefund(t *testing.T) {
+	service, store := newTestService()
+	userID, adminID := uuid.New(), uuid.New()
+	keyboard := seedProduct(store, "Keyboard", 150000, 10)
+	order := createOrder(t, service, userID, OrderItemRequest{ProductID: keyboard, Quantity: 2})
+
+	// Simulate a verified payment
+	paid := store.Orders[order.ID]
+	paid.Status = models.OrderStatusProcessing
+	store.Orders[order.ID] = paid
+	payment := models.Payment{ID: uuid.New(), OrderID: order.ID, Amount: order.TotalAmount, Status: models.PaymentStatusPaid, CreatedAt: time.Now()}
+	store.Payments[payment.ID] = payment
+
+	// Customers cannot cancel once paid
+	if err := service.CancelOrder(context.Background(), order.ID, policy.Customer(userID)); !IsTransitionError(err) {
+		t.Fatalf("err = %v, want transition error", err)
+	}
+
+	if err := service.CancelOrder(context.Background(), order.ID, policy.Admin(adminID)); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+
+	if len(store.Refunds) != 1 {
+		t.Fatalf("refunds = %d, want 1", len(store.Refunds))
+	}
+	for _, refund := range store.Refunds {
+		if refund.PaymentID != payment.ID || refund.Amount != payment.Amount || refund.Status != models.RefundStatusPending {
+			t.Errorf("refund = %+v, want pending full refund of payment", refund)
+		}
+	}
+	if got := stockOf(store, keyboard); got != 10 {
+		t.Errorf("stock = %d, want 10", got)
+	}
+
+	// Refunded orders cannot be re-opened
+	if _, err := service.ReopenOrder(context.Background(), order.ID, policy.Admin(adminID)); err == nil {
+		t.Error("expected reopen of refunded order to fail")
+	}
+}
+
+func TestReopenOrderReservesStockAgain(t *testing.T) {
+	service, store := newTestService()
+	userID, adminID := uuid.New(), uuid.New()
+	keyboard := seedProduct(store, "Keyboard", 150000, 10)
+	order := createOrder(t, service, userID, OrderItemRequest{ProductID: keyboard, Quantity: 4})
+
+	if err := service.CancelOrder(context.Background(), order.ID, policy.Customer(userID)); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+	reopened, err := service.ReopenOrder(context.Background(), order.ID, policy.Admin(adminID))
+	if err != nil {
+		t.Fatalf("ReopenOrder: %v", err)
+	}
+
+	if reopened.Status != models.OrderStatusCreated || reopened.CanceledAt != nil || reopened.ReopenedAt == nil {
+		t.Errorf("order = %s canceled_at=%v reopened_at=%v, want re-opened", reopened.Status, reopened.CanceledAt, reopened.ReopenedAt)
+	}
+	if got := stockOf(store, keyboard); got != 6 {
+		t.Errorf("stock = %d, want 6", got)
+	}
+}
+
+func TestCancelExpiredOrders(t *testing.T) {
+	service, store := newTestService()
+	keyboard := seedProduct(store, "Keyboard", 150000, 10)
+	stale := createOrder(t, service, uuid.New(), OrderItemRequest{ProductID: keyboard, Quantity: 2})
+	fresh := createOrder(t, service, uuid.New(), OrderItemRequest{ProductID: keyboard, Quantity: 1})
+
+	row := store.Orders[stale.ID]
+	row.CreatedAt = time.Now().Add(-2 * time.Hour)
+	store.Orders[stale.ID] = row
+
+	canceled, err := service.CancelExpiredOrders(context.Background(), time.Hour)
+	if err != nil {
+		t.Fatalf("CancelExpiredOrders: %v", err)
+	}
+
+	if canceled != 1 {
+		t.Errorf("canceled = %d, want 1", canceled)
+	}
+	if got := store.Orders[stale.ID].Status; got != models.OrderStatusCanceled {
+		t.Errorf("stale order status = %s, want %s", got, models.OrderStatusCanceled)
+	}
+	if got := store.Orders[fresh.ID].Status; got != models.OrderStatusCreated {
+		t.Errorf("fresh order status = %s, want %s", got, models.OrderStatusCreated)
+	}
+	if got := stockOf(store, keyboard); got != 9 {
+		t.Errorf("stock = %d, want 9", got)
+	}
+}
+
+// failingProductRepository fails every locked read and stock update of one product, inside
+// transactions too
+type failingProductRepository struct {
+	Repository
+	productID uuid.UUID
+}
+
+func (r *failingProductRepository) Transaction(ctx context.Context, fn func(repo Repository) error) error {
+	return r.Repository.Transaction(ctx, func(repo Repository) error {
+		return fn(&failingProductRepository{Repository: repo, productID: r.productID})
+	})
+}
+
+func (r *failingProductRepository) FindProductByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Product, error) {
+	if id == r.productID {
+		return nil, errors.New("product lookup failed")
+	}
+	return r.Repository.FindProductByIDForUpdate(ctx, id)
+}
+
+func (r *failingProductRepository) UpdateProduct(ctx context.Context, product *models.Product) error {
+	if product.ID == r.productID {
+		return errors.New("stock update failed")
+	}
+	return r.Repository.UpdateProduct(ctx, product)
+}
+
+func TestCancelExpiredOrdersSkipsFailingOrder(t *testing.T) {
+	store := memstore.New()
+	keyboard := seedProduct(store, "Keyboard", 150000, 10)
+	mouse := seedProduct(store, "Mouse", 50000, 10)
+	repo := &failingProductRepository{Repository: NewMemoryRepository(store)}
+	service := NewService(repo)
+
+	broken := createOrder(t, service, uuid.New(), OrderItemRequest{ProductID: keyboard, Quantity: 2})
+	healthy := createOrder(t, service, uuid.New(), OrderItemRequest{ProductID: mouse, Quantity: 1})
+	repo.productID = keyboard
+	for i, id := range []uuid.UUID{broken.ID, healthy.ID} {
+		row := store.Orders[id]
+		row.CreatedAt = time.Now().Add(-time.Duration(3-i) * time.Hour)
+		store.Orders[id] = row
+	}
+
+	canceled, err := service.CancelExpiredOrders(context.Background(), time.Hour)
+	if err != nil {
+		t.Fatalf("CancelExpiredOrders: %v", err)
+	}
+
+	if canceled != 1 {
+		t.Errorf("canceled = %d, want 1", canceled)
+	}
+	if got := store.Orders[broken.ID].Status; got != models.OrderStatusCreated {
+		t.Errorf("failing order status = %s, want %s", got, models.OrderStatusCreated)
+	}
+	if got := store.Orders[healthy.ID].Status; got != models.OrderStatusCanceled {
+		t.Errorf("healthy order status = %s, want %s", got, models.OrderStatusCanceled)
+	}
+}
+
+func TestCancelOrderFailsWhenRestockLookupFails(t *testing.T) {
+	store := memstore.New()
+	keyboard := seedProduct(store, "Keyboard", 150000, 10)
+	repo := &failingProductRepository{Repository: NewMemoryRepository(store)}
+	service := NewService(repo)
+	userID := uuid.New()
+	order := createOrder(t, service, userID, OrderItemRequest{ProductID: keyboard, Quantity: 4})
+	repo.productID = keyboard
+
+	if err := service.CancelOrder(context.Background(), order.ID, policy.Customer(userID)); err == nil {
+		t.Fatal("CancelOrder succeeded, want the lookup error")
+	}
+
+	if got := store.Orders[order.ID].Status; got != models.OrderStatusCreated {
+		t.Errorf("status = %s, want %s", got, models.OrderStatusCreated)
+	}
+	if got := stockOf(store, keyboard); got != 6 {
+		t.Errorf("stock = %d, want 6", got)
+	}
+}
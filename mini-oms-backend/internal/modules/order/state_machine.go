@@ -0,0 +1,140 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mini-oms-backend/internal/apperror"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/policy"
+	"sort"
+	"time"
+)
+
+// transitionRule describes who may move an order into a status
+type transitionRule struct {
+	permission string // Required permission; empty if the order owner may do it
+	action     string // Audit log action
+}
+
+// transitions is the order state machine: from -> to -> rule.
+// Anything not listed here is an illegal transition.
+//
+//	created ──pay──> processing ──ship──> shipped ──complete──> completed
+//	 │    ▲              │
+//	cancel reopen(staff) cancel (staff)
+//	 ▼    │              │
+//	canceled <───────────┘
+var transitions = map[string]map[string]transitionRule{
+	models.OrderStatusCreated: {
+		models.OrderStatusProcessing: {permission: policy.PermPaymentsVerify, action: "ORDER_PAID"},
+		models.OrderStatusCanceled:   {action: "ORDER_CANCELED"},
+	},
+	models.OrderStatusProcessing: {
+		models.OrderStatusShipped:  {permission: policy.PermOrdersFulfill, action: "ORDER_SHIPPED"},
+		models.OrderStatusCanceled: {permission: policy.PermOrdersCancel, action: "ORDER_CANCELED"},
+	},
+	models.OrderStatusShipped: {
+		models.OrderStatusCompleted: {permission: policy.PermOrdersFulfill, action: "ORDER_COMPLETED"},
+	},
+	models.OrderStatusCanceled: {
+		models.OrderStatusCreated: {permission: policy.PermOrdersCancel, action: "ORDER_REOPENED"},
+	},
+}
+
+// TransitionError is returned when a status change is not allowed by the state machine
+type TransitionError struct {
+	From   string
+	To     string
+	Reason string
+}
+
+func (e *TransitionError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("cannot change order status from %s to %s: %s", e.From, e.To, e.Reason)
+	}
+	return fmt.Sprintf("cannot change order status from %s to %s", e.From, e.To)
+}
+
+// Is makes errors.Is(err, apperror.ErrInvalidTransition) match, so it is answered with 409
+func (e *TransitionError) Is(target error) bool {
+	return target == apperror.ErrInvalidTransition
+}
+
+// ErrorDetails implements apperror.Detailer
+func (e *TransitionError) ErrorDetails() map[string]interface{} {
+	return map[string]interface{}{
+		"from":    e.From,
+		"to":      e.To,
+		"allowed": AllowedTransitions(e.From),
+	}
+}
+
+// ValidateTransition checks if actor may move an order from one status to another.
+// Ownership is checked by the caller, see Authorize.
+func ValidateTransition(from, to string, actor policy.Actor) error {
+	rule, ok := transitions[from][to]
+	if !ok {
+		return &TransitionError{From: from, To: to}
+	}
+	if rule.permission != "" && !actor.Can(rule.permission) {
+		return &TransitionError{From: from, To: to, Reason: "requires " + rule.permission + " permission"}
+	}
+	return nil
+}
+
+// AllowedTransitions lists the statuses an order can move to from its current status
+func AllowedTransitions(from string) []string {
+	var next []string
+	for to := range transitions[from] {
+		next = append(next, to)
+	}
+	sort.Strings(next)
+	return next
+}
+
+// ApplyTransition validates and persists a status change within repo's transaction and records it in the audit log.
+// The update is guarded on the current status so concurrent transitions cannot both succeed.
+func ApplyTransition(ctx context.Context, repo Repository, order *models.Order, to string, actor policy.Actor, details string) error {
+	from := order.Status
+	if err := ValidateTransition(from, to, actor); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	next := *order
+	next.Status = to
+	switch to {
+	case models.OrderStatusShipped:
+		next.ShippedAt = &now
+	case models.OrderStatusCompleted:
+		next.CompletedAt = &now
+	case models.OrderStatusCanceled:
+		next.CanceledAt = &now
+	case models.OrderStatusCreated:
+		// Re-opened: restart the payment window
+		next.CanceledAt = nil
+		next.ReopenedAt = &now
+	}
+
+	saved, err := repo.SaveOrderStatus(ctx, &next, from)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		return &TransitionError{From: from, To: to, Reason: "order was modified concurrently"}
+	}
+	*order = next
+
+	message := fmt.Sprintf("Status changed from %s to %s by %s", from, to, actor.Role)
+	if details != "" {
+		message += ": " + details
+	}
+	return repo.LogAudit(ctx, actor.UserID, transitions[from][to].action, "Order", order.ID, message)
+}
+
+// IsTransitionError reports whether err is a state machine rejection
+func IsTransitionError(err error) bool {
+	var te *TransitionError
+	return errors.As(err, &te)
+}
@@ -2,6 +2,7 @@ package payment
 
 import (
 	"fmt"
+	"mini-oms-backend/internal/policy"
 	"mini-oms-backend/internal/utils"
 	"net/http"
 
@@ -24,9 +25,9 @@ func (h *Handler) GetByOrderID(c echo.Context) error {
 		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid order ID")
 	}
 
-	payment, err := h.service.GetByOrderID(orderID)
+	payment, err := h.service.GetByOrderID(c.Request().Context(), orderID, policy.ActorFromContext(c))
 	if err != nil {
-		return utils.ErrorResponse(c, http.StatusNotFound, "Payment not found")
+		return err
 	}
 
 	return utils.SuccessResponse(c, http.StatusOK, "Payment retrieved successfully", payment)
@@ -36,24 +37,28 @@ func (h *Handler) GetByOrderID(c echo.Context) error {
 func (h *Handler) Create(c echo.Context) error {
 	var req CreatePaymentRequest
 	if err := c.Bind(&req); err != nil {
-		utils.LogError("PaymentService", "", "CreatePayment", err, "Failed to bind request")
+		utils.LogError(c.Request().Context(), "PaymentService", "", "CreatePayment", err, "Failed to bind request")
 		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
 	}
 
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
 	// Log incoming request
-	utils.LogInfo("PaymentService", req.OrderID.String(), "CreatePayment", fmt.Sprintf("Payment method: %s", req.PaymentMethod))
+	utils.LogInfo(c.Request().Context(), "PaymentService", req.OrderID.String(), "CreatePayment", fmt.Sprintf("Payment method: %s", req.PaymentMethod))
 
-	payment, err := h.service.CreatePayment(&req)
+	payment, err := h.service.CreatePayment(c.Request().Context(), policy.ActorFromContext(c), &req)
 	if err != nil {
-		utils.LogError("PaymentService", req.OrderID.String(), "CreatePayment", err, "Failed to create payment")
-		return utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		utils.LogError(c.Request().Context(), "PaymentService", req.OrderID.String(), "CreatePayment", err, "Failed to create payment")
+		return err
 	}
 	// Public response for creation
-	utils.LogInfo("PaymentService", payment.ID.String(), "CreatePayment", "Payment created successfully")
+	utils.LogInfo(c.Request().Context(), "PaymentService", payment.ID.String(), "CreatePayment", "Payment created successfully")
 	return utils.SuccessResponse(c, http.StatusCreated, "Payment created successfully", payment)
 }
 
-// Verify verifies a payment (Admin only)
+// Verify verifies a payment (payments:verify)
 // @Summary Verify payment
 // @Tags payments
 // @Accept json
@@ -68,17 +73,54 @@ func (h *Handler) Verify(c echo.Context) error {
 		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payment ID")
 	}
 
-	// Get admin ID from context
-	adminID := c.Get("user_id").(uuid.UUID)
+	actor := policy.ActorFromContext(c)
 
-	utils.LogInfo("PaymentService", paymentID.String(), "VerifyPayment", fmt.Sprintf("Verification requested by admin %s", adminID))
+	utils.LogInfo(c.Request().Context(), "PaymentService", paymentID.String(), "VerifyPayment", fmt.Sprintf("Verification requested by %s %s", actor.Role, actor.UserID))
 
-	payment, err := h.service.VerifyPayment(paymentID, adminID)
+	payment, err := h.service.VerifyPayment(c.Request().Context(), paymentID, actor)
 	if err != nil {
-		utils.LogError("PaymentService", paymentID.String(), "VerifyPayment", err, "Verification failed")
-		return utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		utils.LogError(c.Request().Context(), "PaymentService", paymentID.String(), "VerifyPayment", err, "Verification failed")
+		return err
 	}
 
-	utils.LogInfo("PaymentService", paymentID.String(), "VerifyPayment", "Payment verified successfully")
+	utils.LogInfo(c.Request().Context(), "PaymentService", paymentID.String(), "VerifyPayment", "Payment verified successfully")
 	return utils.SuccessResponse(c, http.StatusOK, "Payment verified successfully", payment)
 }
+
+// Reject rejects a pending payment (payments:verify)
+// @Summary Reject payment
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Param request body RejectPaymentRequest true "Rejection reason"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/payments/{id}/reject [post]
+func (h *Handler) Reject(c echo.Context) error {
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payment ID")
+	}
+
+	var req RejectPaymentRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	adminID := c.Get("user_id").(uuid.UUID)
+
+	utils.LogInfo(c.Request().Context(), "PaymentService", paymentID.String(), "RejectPayment", fmt.Sprintf("Rejection requested by admin %s", adminID))
+
+	payment, err := h.service.RejectPayment(c.Request().Context(), paymentID, adminID, &req)
+	if err != nil {
+		utils.LogError(c.Request().Context(), "PaymentService", paymentID.String(), "RejectPayment", err, "Rejection failed")
+		return err
+	}
+
+	utils.LogInfo(c.Request().Context(), "PaymentService", paymentID.String(), "RejectPayment", "Payment rejected successfully")
+	return utils.SuccessResponse(c, http.StatusOK, "Payment rejected successfully", payment)
+}
@@ -0,0 +1,95 @@
+package payment
+
+import (
+	"context"
+	"mini-oms-backend/internal/memstore"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/modules/order"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// memoryRepository implements Repository on top of memstore, for tests
+type memoryRepository struct {
+	store *memstore.Store
+}
+
+func NewMemoryRepository(store *memstore.Store) Repository {
+	return &memoryRepository{store: store}
+}
+
+func (r *memoryRepository) Transaction(ctx context.Context, fn func(repo Repository) error) error {
+	return r.store.Transaction(ctx, func() error {
+		return fn(r)
+	})
+}
+
+func (r *memoryRepository) FindByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Payment, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	var latest *models.Payment
+	for _, payment := range r.store.Payments {
+		if payment.OrderID != orderID {
+			continue
+		}
+		if latest == nil || payment.CreatedAt.After(latest.CreatedAt) {
+			latest = &payment
+		}
+	}
+	if latest == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if order, ok := r.store.Orders[orderID]; ok {
+		latest.Order = &order
+	}
+	return latest, nil
+}
+
+func (r *memoryRepository) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Payment, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	payment, ok := r.store.Payments[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &payment, nil
+}
+
+func (r *memoryRepository) Create(ctx context.Context, payment *models.Payment) error {
+	if err := payment.BeforeCreate(nil); err != nil {
+		return err
+	}
+	now := time.Now()
+	payment.CreatedAt = now
+	payment.UpdatedAt = now
+
+	return r.save(payment)
+}
+
+func (r *memoryRepository) UpdateStatus(ctx context.Context, payment *models.Payment) error {
+	payment.UpdatedAt = time.Now()
+	return r.save(payment)
+}
+
+func (r *memoryRepository) save(payment *models.Payment) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	row := *payment
+	row.Order = nil
+	row.Verifier = nil
+	r.store.Payments[payment.ID] = row
+	return nil
+}
+
+func (r *memoryRepository) Orders() order.Repository {
+	return order.NewMemoryRepository(r.store)
+}
+
+func (r *memoryRepository) LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error {
+	return r.store.LogAudit(ctx, userID, action, entityName, entityID, details)
+}
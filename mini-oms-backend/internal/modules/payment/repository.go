@@ -1,43 +1,78 @@
 package payment
 
 import (
+	"context"
 	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/modules/order"
+	"mini-oms-backend/internal/utils"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-type Repository struct {
+// Repository is the payment data access used by Service.
+// Methods named ...ForUpdate lock the row and must be called within Transaction.
+type Repository interface {
+	// Transaction runs fn as a unit of work; repo is bound to the transaction
+	Transaction(ctx context.Context, fn func(repo Repository) error) error
+
+	FindByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Payment, error)
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Payment, error)
+	Create(ctx context.Context, payment *models.Payment) error
+	UpdateStatus(ctx context.Context, payment *models.Payment) error
+
+	// Orders returns the order repository sharing this repository's transaction
+	Orders() order.Repository
+
+	LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error
+}
+
+type gormRepository struct {
 	db *gorm.DB
 }
 
-func NewRepository(db *gorm.DB) *Repository {
-	return &Repository{db: db}
+func NewRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) Transaction(ctx context.Context, fn func(repo Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&gormRepository{db: tx})
+	})
 }
 
-func (r *Repository) FindByOrderID(orderID uuid.UUID) (*models.Payment, error) {
+// FindByOrderID returns the latest payment attempt for an order
+func (r *gormRepository) FindByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Payment, error) {
 	var payment models.Payment
-	err := r.db.Preload("Order").First(&payment, "order_id = ?", orderID).Error
+	err := r.db.WithContext(ctx).Preload("Order").Order("created_at DESC").First(&payment, "order_id = ?", orderID).Error
 	if err != nil {
 		return nil, err
 	}
 	return &payment, nil
 }
 
-func (r *Repository) Create(payment *models.Payment) error {
-	return r.db.Create(payment).Error
+// FindByIDForUpdate finds a payment and locks the row so verify and reject cannot race
+func (r *gormRepository) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Payment, error) {
+	var payment models.Payment
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *gormRepository) Create(ctx context.Context, payment *models.Payment) error {
+	return r.db.WithContext(ctx).Create(payment).Error
 }
 
-func (r *Repository) FindOrderByID(id uuid.UUID) (*models.Order, error) {
-	var order models.Order
-	err := r.db.First(&order, "id = ?", id).Error
-	return &order, err
+func (r *gormRepository) UpdateStatus(ctx context.Context, payment *models.Payment) error {
+	return r.db.WithContext(ctx).Save(payment).Error
 }
 
-func (r *Repository) UpdateStatus(payment *models.Payment) error {
-	return r.db.Save(payment).Error
+func (r *gormRepository) Orders() order.Repository {
+	return order.NewRepository(r.db)
 }
 
-func (r *Repository) UpdateOrderStatus(orderID uuid.UUID, status string) error {
-	return r.db.Model(&models.Order{}).Where("id = ?", orderID).Update("status", status).Error
+func (r *gormRepository) LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error {
+	return utils.LogAudit(r.db.WithContext(ctx), userID, action, entityName, entityID, details)
 }
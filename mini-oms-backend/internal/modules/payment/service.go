@@ -1,46 +1,66 @@
 package payment
 
 import (
-	"errors"
+	"context"
+	"mini-oms-backend/internal/apperror"
+	"mini-oms-backend/internal/metrics"
 	"mini-oms-backend/internal/models"
-	"mini-oms-backend/internal/utils"
-	"time"
+	"mini-oms-backend/internal/modules/order"
+	"mini-oms-backend/internal/policy"
+	"strings"
 
 	"github.com/google/uuid"
 )
 
 type Service struct {
-	repo *Repository
+	repo Repository
 }
 
-func NewService(repo *Repository) *Service {
+func NewService(repo Repository) *Service {
 	return &Service{repo: repo}
 }
 
+var ErrPaymentNotFound = apperror.NotFound("payment not found")
+
 type CreatePaymentRequest struct {
-	OrderID         uuid.UUID `json:"order_id"`
-	PaymentMethod   string    `json:"payment_method"` // bank_transfer, e-wallet, credit_card
-	PaymentProofURL string    `json:"payment_proof_url"`
-	Notes           string    `json:"notes"`
+	OrderID         uuid.UUID `json:"order_id" validate:"required"`
+	PaymentMethod   string    `json:"payment_method" validate:"required,oneof=bank_transfer e-wallet credit_card"`
+	PaymentProofURL string    `json:"payment_proof_url" validate:"omitempty,url,max=500"`
+	Notes           string    `json:"notes" validate:"max=1000"`
 }
 
-func (s *Service) GetByOrderID(orderID uuid.UUID) (*models.Payment, error) {
-	return s.repo.FindByOrderID(orderID)
+// GetByOrderID returns the latest payment of an order the caller owns; staff with orders:read may read any
+func (s *Service) GetByOrderID(ctx context.Context, orderID uuid.UUID, actor policy.Actor) (*models.Payment, error) {
+	if _, err := order.Authorize(ctx, s.repo.Orders(), orderID, actor, policy.PermOrdersRead, "view payment"); err != nil {
+		return nil, err
+	}
+
+	payment, err := s.repo.FindByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, apperror.MapNotFound(err, ErrPaymentNotFound)
+	}
+	return payment, nil
 }
 
-func (s *Service) CreatePayment(req *CreatePaymentRequest) (*models.Payment, error) {
-	// Check if order exists
-	order, err := s.repo.FindOrderByID(req.OrderID)
+// CreatePayment submits a payment for an order the caller owns (or any order, for staff with payments:verify)
+func (s *Service) CreatePayment(ctx context.Context, actor policy.Actor, req *CreatePaymentRequest) (*models.Payment, error) {
+	// Check if order exists and belongs to the caller
+	unpaidOrder, err := order.Authorize(ctx, s.repo.Orders(), req.OrderID, actor, policy.PermPaymentsVerify, "pay")
 	if err != nil {
-		return nil, errors.New("order not found")
+		return nil, err
+	}
+
+	// Only unpaid orders accept payments
+	if unpaidOrder.Status != models.OrderStatusCreated {
+		return nil, apperror.Conflict("order is not awaiting payment")
 	}
 
 	// Check if payment already exists for this order
-	existingPayment, err := s.repo.FindByOrderID(req.OrderID)
+	existingPayment, err := s.repo.FindByOrderID(ctx, req.OrderID)
 	if err == nil && existingPayment.ID != uuid.Nil {
-		// If existing payment is pending, return error
-		if existingPayment.Status == "pending" || existingPayment.Status == "success" {
-			return nil, errors.New("payment already exists for this order")
+		// If existing payment is pending, return error (a rejected payment may be resubmitted)
+		if existingPayment.Status == models.PaymentStatusPending || existingPayment.Status == models.PaymentStatusPaid {
+			return nil, apperror.Conflict("payment already exists for this order")
 		}
 	}
 
@@ -51,7 +71,7 @@ func (s *Service) CreatePayment(req *CreatePaymentRequest) (*models.Payment, err
 		"credit_card":   true,
 	}
 	if !validMethods[req.PaymentMethod] {
-		return nil, errors.New("invalid payment method")
+		return nil, apperror.InvalidInput("invalid payment method")
 	}
 
 	// Make PaymentProofURL optional (default to "-")
@@ -63,73 +83,96 @@ func (s *Service) CreatePayment(req *CreatePaymentRequest) (*models.Payment, err
 	// Create payment
 	payment := &models.Payment{
 		OrderID:         req.OrderID,
-		Amount:          order.TotalAmount,
+		Amount:          unpaidOrder.TotalAmount,
 		PaymentMethod:   req.PaymentMethod,
-		Status:          "pending",
+		Status:          models.PaymentStatusPending,
 		PaymentProofURL: proofURL,
 		Notes:           req.Notes,
 	}
 
-	if err := s.repo.Create(payment); err != nil {
+	if err := s.repo.Create(ctx, payment); err != nil {
 		return nil, err
 	}
 
 	return payment, nil
 }
 
-func (s *Service) VerifyPayment(paymentID uuid.UUID, adminID uuid.UUID) (*models.Payment, error) {
-	// Start transaction
-	tx := s.repo.db.Begin()
-	if tx.Error != nil {
-		return nil, tx.Error
-	}
+func (s *Service) VerifyPayment(ctx context.Context, paymentID uuid.UUID, actor policy.Actor) (*models.Payment, error) {
+	var payment *models.Payment
+	err := s.repo.Transaction(ctx, func(repo Repository) error {
+		// Find payment with LOCK so verify and reject cannot race
+		var err error
+		payment, err = repo.FindByIDForUpdate(ctx, paymentID)
+		if err != nil {
+			return apperror.MapNotFound(err, ErrPaymentNotFound)
+		}
 
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+		// Update payment status (payment state machine: pending -> paid)
+		if err := payment.MarkAsPaid(actor.UserID); err != nil {
+			return err
 		}
-	}()
 
-	// Find payment
-	var payment models.Payment
-	if err := tx.First(&payment, "id = ?", paymentID).Error; err != nil {
-		tx.Rollback()
-		return nil, errors.New("payment not found")
-	}
+		if err := repo.UpdateStatus(ctx, payment); err != nil {
+			return err
+		}
 
-	if payment.Status == "success" {
-		tx.Rollback()
-		return nil, errors.New("payment already verified")
-	}
+		// Update order status to 'processing' (Paid) through the order state machine
+		orders := repo.Orders()
+		paidOrder, err := orders.FindByIDForUpdate(ctx, payment.OrderID)
+		if err != nil {
+			return apperror.MapNotFound(err, order.ErrOrderNotFound)
+		}
 
-	// Update payment status
-	now := time.Now()
-	payment.Status = "success"
-	payment.VerifiedBy = &adminID
-	payment.VerifiedAt = &now
+		if err := order.ApplyTransition(ctx, orders, paidOrder, models.OrderStatusProcessing, actor, "payment "+payment.PaymentNumber+" verified"); err != nil {
+			return err
+		}
 
-	if err := tx.Save(&payment).Error; err != nil {
-		tx.Rollback()
+		// Log Audit
+		return repo.LogAudit(ctx, actor.UserID, "PAYMENT_VERIFIED", "Payment", payment.ID, "Payment verified by "+actor.Role)
+	})
+	if err != nil {
 		return nil, err
 	}
+	metrics.PaymentsVerified.Inc()
 
-	// Update order status to 'processing' (Paid)
-	// We update directly the order table
-	if err := tx.Model(&models.Order{}).Where("id = ?", payment.OrderID).Update("status", models.OrderStatusProcessing).Error; err != nil {
-		tx.Rollback()
-		return nil, err
-	}
+	return payment, nil
+}
 
-	// Log Audit
-	if err := utils.LogAudit(tx, adminID, "PAYMENT_VERIFIED", "Payment", payment.ID, "Payment verified by admin"); err != nil {
-		tx.Rollback()
-		return nil, err
+type RejectPaymentRequest struct {
+	Reason string `json:"reason" validate:"required,max=1000"`
+}
+
+// RejectPayment marks a pending payment as failed so the customer can submit a new one
+func (s *Service) RejectPayment(ctx context.Context, paymentID uuid.UUID, adminID uuid.UUID, req *RejectPaymentRequest) (*models.Payment, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, apperror.InvalidInput("rejection reason is required")
 	}
 
-	// Commit
-	if err := tx.Commit().Error; err != nil {
+	var payment *models.Payment
+	err := s.repo.Transaction(ctx, func(repo Repository) error {
+		// Lock payment so verify and reject cannot race
+		var err error
+		payment, err = repo.FindByIDForUpdate(ctx, paymentID)
+		if err != nil {
+			return apperror.MapNotFound(err, ErrPaymentNotFound)
+		}
+
+		// Payment state machine: pending -> failed
+		if err := payment.MarkAsFailed(adminID, reason); err != nil {
+			return err
+		}
+
+		if err := repo.UpdateStatus(ctx, payment); err != nil {
+			return err
+		}
+
+		return repo.LogAudit(ctx, adminID, "PAYMENT_REJECTED", "Payment", payment.ID, "Payment rejected by admin: "+reason)
+	})
+	if err != nil {
 		return nil, err
 	}
+	metrics.PaymentsRejected.Inc()
 
-	return &payment, nil
+	return payment, nil
 }
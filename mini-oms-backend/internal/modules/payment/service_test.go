@@ -0,0 +1,226 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"mini-oms-backend/internal/memstore"
+	"mini-oms-backend/internal/metrics"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/modules/order"
+	"mini-oms-backend/internal/policy"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fixture is an unpaid order with a pending payment
+type fixture struct {
+	store     *memstore.Store
+	service   *Service
+	orders    *order.Service
+	ownerID   uuid.UUID
+	orderID   uuid.UUID
+	paymentID uuid.UUID
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	store := memstore.New()
+	f := &fixture{
+		store:   store,
+		service: NewService(NewMemoryRepository(store)),
+		orders:  order.NewService(order.NewMemoryRepository(store)),
+		ownerID: uuid.New(),
+	}
+
+	product := models.Product{ID: uuid.New(), Name: "Keyboard", Price: 150000, Stock: 10, CreatedAt: time.Now()}
+	store.Products[product.ID] = product
+
+	created, err := f.orders.CreateOrder(context.Background(), f.ownerID, &order.CreateOrderRequest{
+		Items: []order.OrderItemRequest{{ProductID: product.ID, Quantity: 2}},
+	})
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	f.orderID = created.ID
+
+	payment, err := f.service.CreatePayment(context.Background(), policy.Customer(f.ownerID), &CreatePaymentRequest{OrderID: created.ID, PaymentMethod: "bank_transfer"})
+	if err != nil {
+		t.Fatalf("CreatePayment: %v", err)
+	}
+	f.paymentID = payment.ID
+
+	return f
+}
+
+func (f *fixture) orderStatus() string {
+	return f.store.Orders[f.orderID].Status
+}
+
+func (f *fixture) paymentStatus() models.PaymentStatus {
+	return f.store.Payments[f.paymentID].Status
+}
+
+func TestCreatePaymentUsesOrderTotal(t *testing.T) {
+	f := newFixture(t)
+
+	payment := f.store.Payments[f.paymentID]
+	if payment.Amount != 300000 {
+		t.Errorf("amount = %.2f, want 300000", payment.Amount)
+	}
+	if payment.Status != models.PaymentStatusPending {
+		t.Errorf("status = %s, want %s", payment.Status, models.PaymentStatusPending)
+	}
+	if payment.PaymentProofURL != "-" {
+		t.Errorf("proof url = %q, want -", payment.PaymentProofURL)
+	}
+}
+
+func TestCreatePaymentRejectsDuplicate(t *testing.T) {
+	f := newFixture(t)
+
+	_, err := f.service.CreatePayment(context.Background(), policy.Customer(f.ownerID), &CreatePaymentRequest{OrderID: f.orderID, PaymentMethod: "e-wallet"})
+	if err == nil {
+		t.Fatal("expected error for second pending payment")
+	}
+}
+
+func TestPaymentsOfAnotherUsersOrderAreHidden(t *testing.T) {
+	f := newFixture(t)
+	strangerID := uuid.New()
+
+	if _, err := f.service.GetByOrderID(context.Background(), f.orderID, policy.Customer(strangerID)); !errors.Is(err, order.ErrOrderNotFound) {
+		t.Fatalf("GetByOrderID: err = %v, want ErrOrderNotFound", err)
+	}
+	if _, err := f.service.CreatePayment(context.Background(), policy.Customer(strangerID), &CreatePaymentRequest{OrderID: f.orderID, PaymentMethod: "e-wallet"}); !errors.Is(err, order.ErrOrderNotFound) {
+		t.Fatalf("CreatePayment: err = %v, want ErrOrderNotFound", err)
+	}
+	if actions := f.store.AuditActions(f.orderID); !slices.Contains(actions, policy.ActionAccessDenied) {
+		t.Errorf("audit = %v, want %s", actions, policy.ActionAccessDenied)
+	}
+
+	if _, err := f.service.GetByOrderID(context.Background(), f.orderID, policy.Customer(f.ownerID)); err != nil {
+		t.Fatalf("owner: %v", err)
+	}
+	if _, err := f.service.GetByOrderID(context.Background(), f.orderID, policy.Admin(uuid.New())); err != nil {
+		t.Fatalf("admin: %v", err)
+	}
+}
+
+func TestCreatePaymentInvalidMethod(t *testing.T) {
+	f := newFixture(t)
+	if _, err := f.service.RejectPayment(context.Background(), f.paymentID, uuid.New(), &RejectPaymentRequest{Reason: "blurry proof"}); err != nil {
+		t.Fatalf("RejectPayment: %v", err)
+	}
+
+	if _, err := f.service.CreatePayment(context.Background(), policy.Customer(f.ownerID), &CreatePaymentRequest{OrderID: f.orderID, PaymentMethod: "cash"}); err == nil {
+		t.Fatal("expected invalid payment method error")
+	}
+}
+
+func TestVerifyPaymentMarksOrderPaid(t *testing.T) {
+	f := newFixture(t)
+	adminID := uuid.New()
+	verified := testutil.ToFloat64(metrics.PaymentsVerified)
+
+	payment, err := f.service.VerifyPayment(context.Background(), f.paymentID, policy.Admin(adminID))
+	if err != nil {
+		t.Fatalf("VerifyPayment: %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.PaymentsVerified) - verified; got != 1 {
+		t.Errorf("payments verified += %v, want 1", got)
+	}
+
+	if payment.Status != models.PaymentStatusPaid || payment.VerifiedBy == nil || *payment.VerifiedBy != adminID {
+		t.Errorf("payment = %s verified_by=%v, want paid by admin", payment.Status, payment.VerifiedBy)
+	}
+	if f.paymentStatus() != models.PaymentStatusPaid {
+		t.Errorf("stored payment status = %s, want %s", f.paymentStatus(), models.PaymentStatusPaid)
+	}
+	if f.orderStatus() != models.OrderStatusProcessing {
+		t.Errorf("order status = %s, want %s", f.orderStatus(), models.OrderStatusProcessing)
+	}
+	if actions := f.store.AuditActions(f.paymentID); !slices.Equal(actions, []string{"PAYMENT_VERIFIED"}) {
+		t.Errorf("payment audit = %v, want [PAYMENT_VERIFIED]", actions)
+	}
+	if actions := f.store.AuditActions(f.orderID); !slices.Contains(actions, "ORDER_PAID") {
+		t.Errorf("order audit = %v, want ORDER_PAID", actions)
+	}
+}
+
+func TestVerifyPaymentTwice(t *testing.T) {
+	f := newFixture(t)
+
+	if _, err := f.service.VerifyPayment(context.Background(), f.paymentID, policy.Admin(uuid.New())); err != nil {
+		t.Fatalf("VerifyPayment: %v", err)
+	}
+
+	_, err := f.service.VerifyPayment(context.Background(), f.paymentID, policy.Admin(uuid.New()))
+	var transitionErr *models.PaymentTransitionError
+	if !errors.As(err, &transitionErr) {
+		t.Fatalf("err = %v, want payment transition error", err)
+	}
+}
+
+func TestVerifyPaymentOfCanceledOrderRollsBack(t *testing.T) {
+	f := newFixture(t)
+
+	// The order is canceled while its payment is still waiting for verification
+	canceled := f.store.Orders[f.orderID]
+	canceled.Status = models.OrderStatusCanceled
+	f.store.Orders[f.orderID] = canceled
+
+	_, err := f.service.VerifyPayment(context.Background(), f.paymentID, policy.Admin(uuid.New()))
+	if !order.IsTransitionError(err) {
+		t.Fatalf("err = %v, want order transition error", err)
+	}
+
+	// The payment update made before the order transition failed must be rolled back
+	if f.paymentStatus() != models.PaymentStatusPending {
+		t.Errorf("payment status = %s, want %s", f.paymentStatus(), models.PaymentStatusPending)
+	}
+	if actions := f.store.AuditActions(f.paymentID); len(actions) != 0 {
+		t.Errorf("payment audit = %v, want none", actions)
+	}
+}
+
+func TestVerifyUnknownPayment(t *testing.T) {
+	f := newFixture(t)
+
+	if _, err := f.service.VerifyPayment(context.Background(), uuid.New(), policy.Admin(uuid.New())); err == nil || err.Error() != "payment not found" {
+		t.Fatalf("err = %v, want payment not found", err)
+	}
+}
+
+func TestRejectPaymentAllowsResubmission(t *testing.T) {
+	f := newFixture(t)
+
+	if _, err := f.service.RejectPayment(context.Background(), f.paymentID, uuid.New(), &RejectPaymentRequest{Reason: " "}); err == nil {
+		t.Fatal("expected error for empty rejection reason")
+	}
+
+	rejected, err := f.service.RejectPayment(context.Background(), f.paymentID, uuid.New(), &RejectPaymentRequest{Reason: "amount mismatch"})
+	if err != nil {
+		t.Fatalf("RejectPayment: %v", err)
+	}
+	if rejected.Status != models.PaymentStatusFailed {
+		t.Errorf("status = %s, want %s", rejected.Status, models.PaymentStatusFailed)
+	}
+	if f.orderStatus() != models.OrderStatusCreated {
+		t.Errorf("order status = %s, want %s", f.orderStatus(), models.OrderStatusCreated)
+	}
+
+	resubmitted, err := f.service.CreatePayment(context.Background(), policy.Customer(f.ownerID), &CreatePaymentRequest{OrderID: f.orderID, PaymentMethod: "e-wallet"})
+	if err != nil {
+		t.Fatalf("CreatePayment after rejection: %v", err)
+	}
+	if _, err := f.service.VerifyPayment(context.Background(), resubmitted.ID, policy.Admin(uuid.New())); err != nil {
+		t.Fatalf("VerifyPayment of resubmission: %v", err)
+	}
+	if f.orderStatus() != models.OrderStatusProcessing {
+		t.Errorf("order status = %s, want %s", f.orderStatus(), models.OrderStatusProcessing)
+	}
+}
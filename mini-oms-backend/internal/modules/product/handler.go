@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"mini-oms-backend/internal/utils"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -17,20 +19,92 @@ func NewHandler(service *Service) *Handler {
 	return &Handler{service: service}
 }
 
-// GetAll returns all products
+// GetAll returns all products, optionally filtered by ?category_id=
 func (h *Handler) GetAll(c echo.Context) error {
-	utils.LogInfo("ProductService", "", "GetAllProducts", "Fetching all products")
+	var categoryID *uuid.UUID
+	if param := c.QueryParam("category_id"); param != "" {
+		id, err := uuid.Parse(param)
+		if err != nil {
+			return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid category ID")
+		}
+		categoryID = &id
+	}
+
+	utils.LogInfo(c.Request().Context(), "ProductService", "", "GetAllProducts", "Fetching all products")
 
-	products, err := h.service.GetAll()
+	products, err := h.service.GetAll(c.Request().Context(), categoryID)
 	if err != nil {
-		utils.LogError("ProductService", "", "GetAllProducts", err, "Failed to fetch products")
+		utils.LogError(c.Request().Context(), "ProductService", "", "GetAllProducts", err, "Failed to fetch products")
 		return utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch products")
 	}
 
-	utils.LogInfo("ProductService", "", "GetAllProducts", fmt.Sprintf("Retrieved %d products", len(products)))
+	utils.LogInfo(c.Request().Context(), "ProductService", "", "GetAllProducts", fmt.Sprintf("Retrieved %d products", len(products)))
 	return utils.SuccessResponse(c, http.StatusOK, "Products retrieved successfully", products)
 }
 
+// Search searches the catalog
+// Query params: q, category_id, min_price, max_price, in_stock (true/false),
+// sort_by (relevance, price, name, created_at), sort_dir, page, limit
+func (h *Handler) Search(c echo.Context) error {
+	filter := SearchFilter{
+		Query:   strings.TrimSpace(c.QueryParam("q")),
+		SortBy:  c.QueryParam("sort_by"),
+		SortDir: c.QueryParam("sort_dir"),
+	}
+	filter.Page, filter.Limit = utils.ParsePagination(c.QueryParam("page"), c.QueryParam("limit"))
+
+	if filter.SortBy != "" && filter.SortBy != "relevance" {
+		if _, ok := sortableColumns[filter.SortBy]; !ok {
+			return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid sort_by, allowed: relevance, price, name, created_at")
+		}
+	}
+	if filter.SortDir != "" && filter.SortDir != "asc" && filter.SortDir != "desc" {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid sort_dir, allowed: asc, desc")
+	}
+
+	if param := c.QueryParam("category_id"); param != "" {
+		id, err := uuid.Parse(param)
+		if err != nil {
+			return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid category ID")
+		}
+		filter.CategoryID = &id
+	}
+
+	if param := c.QueryParam("min_price"); param != "" {
+		price, err := strconv.ParseFloat(param, 64)
+		if err != nil || price < 0 {
+			return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid min_price")
+		}
+		filter.MinPrice = &price
+	}
+	if param := c.QueryParam("max_price"); param != "" {
+		price, err := strconv.ParseFloat(param, 64)
+		if err != nil || price < 0 {
+			return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid max_price")
+		}
+		filter.MaxPrice = &price
+	}
+
+	if param := c.QueryParam("in_stock"); param != "" {
+		inStock, err := strconv.ParseBool(param)
+		if err != nil {
+			return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid in_stock")
+		}
+		filter.InStockOnly = inStock
+	}
+
+	utils.LogInfo(c.Request().Context(), "ProductService", "", "SearchProducts", "Search query: "+filter.Query)
+
+	products, meta, err := h.service.Search(c.Request().Context(), filter)
+	if err != nil {
+		utils.LogError(c.Request().Context(), "ProductService", "", "SearchProducts", err, "Search failed")
+		return err
+	}
+
+	utils.LogInfo(c.Request().Context(), "ProductService", "", "SearchProducts", fmt.Sprintf("Found %d products", meta.Total))
+	return utils.SuccessResponseWithMeta(c, http.StatusOK, "Products retrieved successfully", products, meta)
+}
+
 // GetByID returns product by ID
 func (h *Handler) GetByID(c echo.Context) error {
 	idParam := c.Param("id")
@@ -39,34 +113,38 @@ func (h *Handler) GetByID(c echo.Context) error {
 		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid product ID")
 	}
 
-	utils.LogInfo("ProductService", id.String(), "GetProduct", "Fetching product details")
+	utils.LogInfo(c.Request().Context(), "ProductService", id.String(), "GetProduct", "Fetching product details")
 
-	product, err := h.service.GetByID(id)
+	product, err := h.service.GetByID(c.Request().Context(), id)
 	if err != nil {
-		utils.LogError("ProductService", id.String(), "GetProduct", err, "Product not found")
-		return utils.ErrorResponse(c, http.StatusNotFound, "Product not found")
+		utils.LogError(c.Request().Context(), "ProductService", id.String(), "GetProduct", err, "Failed to fetch product")
+		return err
 	}
 
-	utils.LogInfo("ProductService", id.String(), "GetProduct", "Product retrieved: "+product.Name)
+	utils.LogInfo(c.Request().Context(), "ProductService", id.String(), "GetProduct", "Product retrieved: "+product.Name)
 	return utils.SuccessResponse(c, http.StatusOK, "Product retrieved successfully", product)
 }
 
-// Create creates new product (admin only)
+// Create creates new product (catalog:write)
 func (h *Handler) Create(c echo.Context) error {
 	var req ProductRequest
 	if err := c.Bind(&req); err != nil {
 		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
 	}
 
-	product, err := h.service.Create(&req)
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	product, err := h.service.Create(c.Request().Context(), &req)
 	if err != nil {
-		return utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return err
 	}
 
 	return utils.SuccessResponse(c, http.StatusCreated, "Product created successfully", product)
 }
 
-// Update updates product (admin only)
+// Update updates product (catalog:write)
 func (h *Handler) Update(c echo.Context) error {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -78,23 +156,27 @@ func (h *Handler) Update(c echo.Context) error {
 		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
 	}
 
-	product, err := h.service.Update(id, &req)
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	product, err := h.service.Update(c.Request().Context(), id, &req)
 	if err != nil {
-		return utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return err
 	}
 
 	return utils.SuccessResponse(c, http.StatusOK, "Product updated successfully", product)
 }
 
-// Delete deletes product (admin only)
+// Delete deletes product (catalog:write)
 func (h *Handler) Delete(c echo.Context) error {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid product ID")
 	}
 
-	if err := h.service.Delete(id); err != nil {
-		return utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete product")
+	if err := h.service.Delete(c.Request().Context(), id); err != nil {
+		return err
 	}
 
 	return utils.SuccessResponse(c, http.StatusOK, "Product deleted successfully", nil)
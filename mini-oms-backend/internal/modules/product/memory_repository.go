@@ -0,0 +1,150 @@
+package product
+
+import (
+	"context"
+	"mini-oms-backend/internal/memstore"
+	"mini-oms-backend/internal/models"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// memoryRepository implements Repository on top of memstore, for tests.
+// Search matches every query word as a case-insensitive substring instead of full-text search.
+type memoryRepository struct {
+	store *memstore.Store
+}
+
+func NewMemoryRepository(store *memstore.Store) Repository {
+	return &memoryRepository{store: store}
+}
+
+func (r *memoryRepository) FindAll(ctx context.Context, categoryID *uuid.UUID) ([]models.Product, error) {
+	return r.find(func(product models.Product) bool {
+		return categoryID == nil || (product.CategoryID != nil && *product.CategoryID == *categoryID)
+	}), nil
+}
+
+func (r *memoryRepository) Search(ctx context.Context, filter SearchFilter) ([]models.Product, int64, error) {
+	words := strings.Fields(strings.ToLower(filter.Query))
+
+	products := r.find(func(product models.Product) bool {
+		text := strings.ToLower(product.Name + " " + product.Description)
+		for _, word := range words {
+			if !strings.Contains(text, word) {
+				return false
+			}
+		}
+		if filter.CategoryID != nil && (product.CategoryID == nil || *product.CategoryID != *filter.CategoryID) {
+			return false
+		}
+		if filter.MinPrice != nil && product.Price < *filter.MinPrice {
+			return false
+		}
+		if filter.MaxPrice != nil && product.Price > *filter.MaxPrice {
+			return false
+		}
+		return !filter.InStockOnly || product.Stock > 0
+	})
+
+	less := func(a, b models.Product) bool {
+		switch filter.SortBy {
+		case "price":
+			return a.Price < b.Price
+		case "name":
+			return a.Name < b.Name
+		default:
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+	}
+	sort.SliceStable(products, func(i, j int) bool {
+		if filter.SortDir == "asc" {
+			return less(products[i], products[j])
+		}
+		return less(products[j], products[i])
+	})
+
+	total := int64(len(products))
+	start := min((filter.Page-1)*filter.Limit, len(products))
+	end := min(start+filter.Limit, len(products))
+	return products[start:end], total, nil
+}
+
+// find returns non-deleted products matching the predicate, with their category
+func (r *memoryRepository) find(match func(product models.Product) bool) []models.Product {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	var products []models.Product
+	for _, product := range r.store.Products {
+		if product.DeletedAt.Valid || !match(product) {
+			continue
+		}
+		if product.CategoryID != nil {
+			if category, ok := r.store.Categories[*product.CategoryID]; ok && !category.DeletedAt.Valid {
+				product.Category = &category
+			}
+		}
+		products = append(products, product)
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].CreatedAt.Before(products[j].CreatedAt) })
+	return products
+}
+
+func (r *memoryRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Product, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	product, ok := r.store.Products[id]
+	if !ok || product.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &product, nil
+}
+
+func (r *memoryRepository) CategoryExists(ctx context.Context, id uuid.UUID) bool {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	category, ok := r.store.Categories[id]
+	return ok && !category.DeletedAt.Valid
+}
+
+func (r *memoryRepository) Create(ctx context.Context, product *models.Product) error {
+	if err := product.BeforeCreate(nil); err != nil {
+		return err
+	}
+	now := time.Now()
+	product.CreatedAt = now
+	product.UpdatedAt = now
+	return r.save(product)
+}
+
+func (r *memoryRepository) Update(ctx context.Context, product *models.Product) error {
+	product.UpdatedAt = time.Now()
+	return r.save(product)
+}
+
+func (r *memoryRepository) save(product *models.Product) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	row := *product
+	row.Category = nil
+	r.store.Products[product.ID] = row
+	return nil
+}
+
+func (r *memoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	if product, ok := r.store.Products[id]; ok {
+		product.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		r.store.Products[id] = product
+	}
+	return nil
+}
@@ -1,49 +1,139 @@
 package product
 
 import (
+	"context"
 	"mini-oms-backend/internal/models"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-type Repository struct {
+// Repository is the catalog data access used by Service
+type Repository interface {
+	FindAll(ctx context.Context, categoryID *uuid.UUID) ([]models.Product, error)
+	Search(ctx context.Context, filter SearchFilter) ([]models.Product, int64, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Product, error)
+	CategoryExists(ctx context.Context, id uuid.UUID) bool
+	Create(ctx context.Context, product *models.Product) error
+	Update(ctx context.Context, product *models.Product) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type gormRepository struct {
 	db *gorm.DB
 }
 
-func NewRepository(db *gorm.DB) *Repository {
-	return &Repository{db: db}
+func NewRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
 }
 
-func (r *Repository) FindAll() ([]models.Product, error) {
+// FindAll returns products, optionally filtered by category
+func (r *gormRepository) FindAll(ctx context.Context, categoryID *uuid.UUID) ([]models.Product, error) {
 	var products []models.Product
-	err := r.db.Preload("Category").Find(&products).Error
+	query := r.db.WithContext(ctx).Preload("Category")
+	if categoryID != nil {
+		query = query.Where("category_id = ?", *categoryID)
+	}
+	err := query.Find(&products).Error
 	return products, err
 }
 
-func (r *Repository) FindByID(id uuid.UUID) (*models.Product, error) {
+// SearchFilter holds catalog search parameters
+type SearchFilter struct {
+	Query       string
+	CategoryID  *uuid.UUID
+	MinPrice    *float64
+	MaxPrice    *float64
+	InStockOnly bool
+	SortBy      string // relevance, price, name, created_at
+	SortDir     string // asc, desc
+	Page        int
+	Limit       int
+}
+
+// searchVector must match the expression of the GIN index in migrations/0005_product_search_index
+const searchVector = "to_tsvector('simple', coalesce(products.name, '') || ' ' || coalesce(products.description, ''))"
+
+// sortableColumns whitelists columns allowed in ORDER BY
+var sortableColumns = map[string]string{
+	"price":      "products.price",
+	"name":       "products.name",
+	"created_at": "products.created_at",
+}
+
+// Search runs a full-text catalog search and returns a page of products with the total count
+func (r *gormRepository) Search(ctx context.Context, filter SearchFilter) ([]models.Product, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Product{})
+
+	if filter.Query != "" {
+		query = query.Where(searchVector+" @@ websearch_to_tsquery('simple', ?)", filter.Query)
+	}
+	if filter.CategoryID != nil {
+		query = query.Where("products.category_id = ?", *filter.CategoryID)
+	}
+	if filter.MinPrice != nil {
+		query = query.Where("products.price >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query = query.Where("products.price <= ?", *filter.MaxPrice)
+	}
+	if filter.InStockOnly {
+		query = query.Where("products.stock > 0")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	direction := "DESC"
+	if filter.SortDir == "asc" {
+		direction = "ASC"
+	}
+
+	if column, ok := sortableColumns[filter.SortBy]; ok {
+		query = query.Order(column + " " + direction)
+	} else if filter.Query != "" {
+		// Relevance: best matches first
+		query = query.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "ts_rank(" + searchVector + ", websearch_to_tsquery('simple', ?)) DESC",
+			Vars: []interface{}{filter.Query},
+		}})
+	} else {
+		query = query.Order("products.created_at DESC")
+	}
+
+	var products []models.Product
+	err := query.Preload("Category").
+		Order("products.id").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&products).Error
+	return products, total, err
+}
+
+func (r *gormRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Product, error) {
 	var product models.Product
-	err := r.db.First(&product, "id = ?", id).Error
+	err := r.db.WithContext(ctx).First(&product, "id = ?", id).Error
 	return &product, err
 }
 
-// FindByIDWithLock finds a product and locks the row for update (Must be called within a transaction)
-func (r *Repository) FindByIDWithLock(tx *gorm.DB, id uuid.UUID) (*models.Product, error) {
-	var product models.Product
-	if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&product, "id = ?", id).Error; err != nil {
-		return nil, err
-	}
-	return &product, nil
+// CategoryExists checks if category exists
+func (r *gormRepository) CategoryExists(ctx context.Context, id uuid.UUID) bool {
+	var count int64
+	r.db.WithContext(ctx).Model(&models.Category{}).Where("id = ?", id).Count(&count)
+	return count > 0
 }
 
-func (r *Repository) Create(product *models.Product) error {
-	return r.db.Create(product).Error
+func (r *gormRepository) Create(ctx context.Context, product *models.Product) error {
+	return r.db.WithContext(ctx).Create(product).Error
 }
 
-func (r *Repository) Update(product *models.Product) error {
-	return r.db.Save(product).Error
+func (r *gormRepository) Update(ctx context.Context, product *models.Product) error {
+	return r.db.WithContext(ctx).Save(product).Error
 }
 
-func (r *Repository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Product{}, "id = ?", id).Error
+func (r *gormRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.Product{}, "id = ?", id).Error
 }
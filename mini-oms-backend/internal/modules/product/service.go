@@ -1,40 +1,69 @@
 package product
 
 import (
-	"errors"
+	"context"
+	"mini-oms-backend/internal/apperror"
 	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/utils"
 
 	"github.com/google/uuid"
 )
 
 type Service struct {
-	repo *Repository
+	repo Repository
 }
 
-func NewService(repo *Repository) *Service {
+func NewService(repo Repository) *Service {
 	return &Service{repo: repo}
 }
 
+var (
+	ErrProductNotFound  = apperror.NotFound("product not found")
+	ErrCategoryNotFound = apperror.NotFound("category not found")
+)
+
 type ProductRequest struct {
 	CategoryID  *uuid.UUID `json:"category_id"`
-	Name        string     `json:"name"`
-	Description string     `json:"description"`
-	Price       float64    `json:"price"`
-	Stock       int        `json:"stock"`
-	ImageURL    string     `json:"image_url"`
+	Name        string     `json:"name" validate:"required,max=255"`
+	Description string     `json:"description" validate:"max=5000"`
+	Price       float64    `json:"price" validate:"gt=0"`
+	Stock       int        `json:"stock" validate:"gte=0"`
+	ImageURL    string     `json:"image_url" validate:"omitempty,url,max=500"`
 }
 
-func (s *Service) GetAll() ([]models.Product, error) {
-	return s.repo.FindAll()
+func (s *Service) GetAll(ctx context.Context, categoryID *uuid.UUID) ([]models.Product, error) {
+	return s.repo.FindAll(ctx, categoryID)
 }
 
-func (s *Service) GetByID(id uuid.UUID) (*models.Product, error) {
-	return s.repo.FindByID(id)
+// Search runs a catalog search and returns pagination meta
+func (s *Service) Search(ctx context.Context, filter SearchFilter) ([]models.Product, utils.PaginationMeta, error) {
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return nil, utils.PaginationMeta{}, apperror.InvalidInput("min_price cannot be greater than max_price")
+	}
+
+	products, total, err := s.repo.Search(ctx, filter)
+	if err != nil {
+		return nil, utils.PaginationMeta{}, err
+	}
+
+	return products, utils.NewPaginationMeta(filter.Page, filter.Limit, total), nil
 }
 
-func (s *Service) Create(req *ProductRequest) (*models.Product, error) {
+func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*models.Product, error) {
+	product, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, apperror.MapNotFound(err, ErrProductNotFound)
+	}
+	return product, nil
+}
+
+func (s *Service) Create(ctx context.Context, req *ProductRequest) (*models.Product, error) {
 	if req.Name == "" || req.Price <= 0 || req.Stock < 0 {
-		return nil, errors.New("invalid product data")
+		return nil, apperror.InvalidInput("invalid product data")
+	}
+
+	if req.CategoryID != nil && !s.repo.CategoryExists(ctx, *req.CategoryID) {
+		return nil, ErrCategoryNotFound
 	}
 
 	product := &models.Product{
@@ -46,17 +75,21 @@ func (s *Service) Create(req *ProductRequest) (*models.Product, error) {
 		ImageURL:    req.ImageURL,
 	}
 
-	if err := s.repo.Create(product); err != nil {
+	if err := s.repo.Create(ctx, product); err != nil {
 		return nil, err
 	}
 
 	return product, nil
 }
 
-func (s *Service) Update(id uuid.UUID, req *ProductRequest) (*models.Product, error) {
-	product, err := s.repo.FindByID(id)
+func (s *Service) Update(ctx context.Context, id uuid.UUID, req *ProductRequest) (*models.Product, error) {
+	product, err := s.repo.FindByID(ctx, id)
 	if err != nil {
-		return nil, errors.New("product not found")
+		return nil, apperror.MapNotFound(err, ErrProductNotFound)
+	}
+
+	if req.CategoryID != nil && !s.repo.CategoryExists(ctx, *req.CategoryID) {
+		return nil, ErrCategoryNotFound
 	}
 
 	product.CategoryID = req.CategoryID
@@ -66,13 +99,16 @@ func (s *Service) Update(id uuid.UUID, req *ProductRequest) (*models.Product, er
 	product.Stock = req.Stock
 	product.ImageURL = req.ImageURL
 
-	if err := s.repo.Update(product); err != nil {
+	if err := s.repo.Update(ctx, product); err != nil {
 		return nil, err
 	}
 
 	return product, nil
 }
 
-func (s *Service) Delete(id uuid.UUID) error {
-	return s.repo.Delete(id)
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.repo.FindByID(ctx, id); err != nil {
+		return apperror.MapNotFound(err, ErrProductNotFound)
+	}
+	return s.repo.Delete(ctx, id)
 }
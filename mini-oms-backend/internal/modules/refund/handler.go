@@ -0,0 +1,144 @@
+package refund
+
+import (
+	"context"
+	"fmt"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/utils"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetAll returns refunds (refunds:manage), optionally filtered by ?payment_id= and ?status=
+func (h *Handler) GetAll(c echo.Context) error {
+	var paymentID *uuid.UUID
+	if param := c.QueryParam("payment_id"); param != "" {
+		id, err := uuid.Parse(param)
+		if err != nil {
+			return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payment ID")
+		}
+		paymentID = &id
+	}
+
+	refunds, err := h.service.GetAll(c.Request().Context(), paymentID, c.QueryParam("status"))
+	if err != nil {
+		utils.LogError(c.Request().Context(), "RefundService", "", "GetAllRefunds", err, "Failed to fetch refunds")
+		return utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch refunds")
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Refunds retrieved successfully", refunds)
+}
+
+// GetByID returns refund by ID (refunds:manage)
+func (h *Handler) GetByID(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid refund ID")
+	}
+
+	refund, err := h.service.GetByID(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Refund retrieved successfully", refund)
+}
+
+// Create requests a full or partial refund for a payment (refunds:manage)
+// @Summary Create refund
+// @Tags refunds
+// @Accept json
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Param request body CreateRefundRequest true "Refund Request"
+// @Success 201 {object} utils.APIResponse
+// @Router /api/payments/{id}/refunds [post]
+func (h *Handler) Create(c echo.Context) error {
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payment ID")
+	}
+
+	var req CreateRefundRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	adminID := c.Get("user_id").(uuid.UUID)
+
+	utils.LogInfo(c.Request().Context(), "RefundService", paymentID.String(), "CreateRefund", fmt.Sprintf("Refund of %.2f requested by admin %s", req.Amount, adminID))
+
+	refund, err := h.service.CreateRefund(c.Request().Context(), paymentID, adminID, &req)
+	if err != nil {
+		utils.LogError(c.Request().Context(), "RefundService", paymentID.String(), "CreateRefund", err, "Failed to create refund")
+		return err
+	}
+
+	utils.LogInfo(c.Request().Context(), "RefundService", refund.ID.String(), "CreateRefund", "Refund created successfully")
+	return utils.SuccessResponse(c, http.StatusCreated, "Refund created successfully", refund)
+}
+
+// Complete marks a pending refund as completed (refunds:manage)
+// @Summary Complete refund
+// @Tags refunds
+// @Accept json
+// @Produce json
+// @Param id path string true "Refund ID"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/refunds/{id}/complete [post]
+func (h *Handler) Complete(c echo.Context) error {
+	return h.process(c, "CompleteRefund", "Refund completed successfully", h.service.CompleteRefund)
+}
+
+// Fail marks a pending refund as failed (refunds:manage)
+// @Summary Fail refund
+// @Tags refunds
+// @Accept json
+// @Produce json
+// @Param id path string true "Refund ID"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/refunds/{id}/fail [post]
+func (h *Handler) Fail(c echo.Context) error {
+	return h.process(c, "FailRefund", "Refund marked as failed", h.service.FailRefund)
+}
+
+func (h *Handler) process(c echo.Context, method, message string, fn func(ctx context.Context, refundID, adminID uuid.UUID, req *ProcessRefundRequest) (*models.Refund, error)) error {
+	refundID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid refund ID")
+	}
+
+	var req ProcessRefundRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	adminID := c.Get("user_id").(uuid.UUID)
+
+	refund, err := fn(c.Request().Context(), refundID, adminID, &req)
+	if err != nil {
+		utils.LogError(c.Request().Context(), "RefundService", refundID.String(), method, err, "Failed to process refund")
+		return err
+	}
+
+	utils.LogInfo(c.Request().Context(), "RefundService", refundID.String(), method, message)
+	return utils.SuccessResponse(c, http.StatusOK, message, refund)
+}
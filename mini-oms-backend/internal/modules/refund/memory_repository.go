@@ -0,0 +1,141 @@
+package refund
+
+import (
+	"context"
+	"mini-oms-backend/internal/memstore"
+	"mini-oms-backend/internal/models"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// memoryRepository implements Repository on top of memstore, for tests
+type memoryRepository struct {
+	store *memstore.Store
+}
+
+func NewMemoryRepository(store *memstore.Store) Repository {
+	return &memoryRepository{store: store}
+}
+
+func (r *memoryRepository) Transaction(ctx context.Context, fn func(repo Repository) error) error {
+	return r.store.Transaction(ctx, func() error {
+		return fn(r)
+	})
+}
+
+func (r *memoryRepository) FindAll(ctx context.Context, paymentID *uuid.UUID, status string) ([]models.Refund, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	var refunds []models.Refund
+	for _, refund := range r.store.Refunds {
+		if paymentID != nil && refund.PaymentID != *paymentID {
+			continue
+		}
+		if status != "" && string(refund.Status) != status {
+			continue
+		}
+		refunds = append(refunds, refund)
+	}
+	sort.Slice(refunds, func(i, j int) bool { return refunds[i].CreatedAt.After(refunds[j].CreatedAt) })
+	return refunds, nil
+}
+
+func (r *memoryRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Refund, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	refund, ok := r.store.Refunds[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if payment, ok := r.store.Payments[refund.PaymentID]; ok {
+		refund.Payment = &payment
+	}
+	return &refund, nil
+}
+
+func (r *memoryRepository) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Refund, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	refund, ok := r.store.Refunds[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &refund, nil
+}
+
+func (r *memoryRepository) SumAmount(ctx context.Context, paymentID uuid.UUID, statuses ...models.RefundStatus) (float64, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	var total float64
+	for _, refund := range r.store.Refunds {
+		if refund.PaymentID == paymentID && slices.Contains(statuses, refund.Status) {
+			total += refund.Amount
+		}
+	}
+	return total, nil
+}
+
+func (r *memoryRepository) Create(ctx context.Context, refund *models.Refund) error {
+	if err := refund.BeforeCreate(nil); err != nil {
+		return err
+	}
+	now := time.Now()
+	refund.CreatedAt = now
+	refund.UpdatedAt = now
+
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	row := *refund
+	row.Payment = nil
+	r.store.Refunds[refund.ID] = row
+	return nil
+}
+
+func (r *memoryRepository) Update(ctx context.Context, refund *models.Refund) error {
+	refund.UpdatedAt = time.Now()
+
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	row := *refund
+	row.Payment = nil
+	r.store.Refunds[refund.ID] = row
+	return nil
+}
+
+func (r *memoryRepository) FindPaymentForUpdate(ctx context.Context, id uuid.UUID) (*models.Payment, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	payment, ok := r.store.Payments[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &payment, nil
+}
+
+func (r *memoryRepository) UpdatePayment(ctx context.Context, payment *models.Payment) error {
+	payment.UpdatedAt = time.Now()
+
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	row := *payment
+	row.Order = nil
+	row.Verifier = nil
+	r.store.Payments[payment.ID] = row
+	return nil
+}
+
+func (r *memoryRepository) LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error {
+	return r.store.LogAudit(ctx, userID, action, entityName, entityID, details)
+}
@@ -0,0 +1,108 @@
+package refund
+
+import (
+	"context"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository is the refund data access used by Service.
+// Methods named ...ForUpdate lock the row and must be called within Transaction.
+type Repository interface {
+	// Transaction runs fn as a unit of work; repo is bound to the transaction
+	Transaction(ctx context.Context, fn func(repo Repository) error) error
+
+	FindAll(ctx context.Context, paymentID *uuid.UUID, status string) ([]models.Refund, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Refund, error)
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Refund, error)
+	// SumAmount sums refund amounts of a payment with the given statuses
+	SumAmount(ctx context.Context, paymentID uuid.UUID, statuses ...models.RefundStatus) (float64, error)
+	Create(ctx context.Context, refund *models.Refund) error
+	Update(ctx context.Context, refund *models.Refund) error
+
+	FindPaymentForUpdate(ctx context.Context, id uuid.UUID) (*models.Payment, error)
+	UpdatePayment(ctx context.Context, payment *models.Payment) error
+
+	LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error
+}
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) Transaction(ctx context.Context, fn func(repo Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&gormRepository{db: tx})
+	})
+}
+
+// FindAll returns refunds, optionally filtered by payment and status
+func (r *gormRepository) FindAll(ctx context.Context, paymentID *uuid.UUID, status string) ([]models.Refund, error) {
+	var refunds []models.Refund
+	query := r.db.WithContext(ctx).Order("created_at DESC")
+	if paymentID != nil {
+		query = query.Where("payment_id = ?", *paymentID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&refunds).Error
+	return refunds, err
+}
+
+func (r *gormRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Refund, error) {
+	var refund models.Refund
+	err := r.db.WithContext(ctx).Preload("Payment").First(&refund, "id = ?", id).Error
+	return &refund, err
+}
+
+// FindByIDForUpdate finds a refund and locks the row
+func (r *gormRepository) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Refund, error) {
+	var refund models.Refund
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&refund, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *gormRepository) SumAmount(ctx context.Context, paymentID uuid.UUID, statuses ...models.RefundStatus) (float64, error) {
+	var total float64
+	err := r.db.WithContext(ctx).Model(&models.Refund{}).
+		Where("payment_id = ? AND status IN ?", paymentID, statuses).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *gormRepository) Create(ctx context.Context, refund *models.Refund) error {
+	return r.db.WithContext(ctx).Create(refund).Error
+}
+
+func (r *gormRepository) Update(ctx context.Context, refund *models.Refund) error {
+	return r.db.WithContext(ctx).Save(refund).Error
+}
+
+// FindPaymentForUpdate finds a payment and locks the row
+func (r *gormRepository) FindPaymentForUpdate(ctx context.Context, id uuid.UUID) (*models.Payment, error) {
+	var payment models.Payment
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *gormRepository) UpdatePayment(ctx context.Context, payment *models.Payment) error {
+	return r.db.WithContext(ctx).Save(payment).Error
+}
+
+func (r *gormRepository) LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error {
+	return utils.LogAudit(r.db.WithContext(ctx), userID, action, entityName, entityID, details)
+}
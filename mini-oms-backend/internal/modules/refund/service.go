@@ -0,0 +1,182 @@
+package refund
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"mini-oms-backend/internal/apperror"
+	"mini-oms-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+var (
+	ErrRefundNotFound  = apperror.NotFound("refund not found")
+	ErrPaymentNotFound = apperror.NotFound("payment not found")
+)
+
+type CreateRefundRequest struct {
+	Amount float64 `json:"amount" validate:"gte=0"` // Optional, defaults to the full refundable amount
+	Reason string  `json:"reason" validate:"required,max=1000"`
+}
+
+type ProcessRefundRequest struct {
+	Notes string `json:"notes" validate:"max=1000"`
+}
+
+func (s *Service) GetAll(ctx context.Context, paymentID *uuid.UUID, status string) ([]models.Refund, error) {
+	return s.repo.FindAll(ctx, paymentID, status)
+}
+
+func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*models.Refund, error) {
+	refund, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, apperror.MapNotFound(err, ErrRefundNotFound)
+	}
+	return refund, nil
+}
+
+// CreateRefund requests a full or partial refund of a paid payment (refunds:manage)
+func (s *Service) CreateRefund(ctx context.Context, paymentID, adminID uuid.UUID, req *CreateRefundRequest) (*models.Refund, error) {
+	if req.Amount < 0 {
+		return nil, apperror.InvalidInput("refund amount must be positive")
+	}
+	if req.Reason == "" {
+		return nil, apperror.InvalidInput("refund reason is required")
+	}
+
+	var refund *models.Refund
+	err := s.repo.Transaction(ctx, func(repo Repository) error {
+		payment, err := repo.FindPaymentForUpdate(ctx, paymentID)
+		if err != nil {
+			return apperror.MapNotFound(err, ErrPaymentNotFound)
+		}
+
+		refund, err = RequestRefund(ctx, repo, payment, req.Amount, req.Reason, adminID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// RequestRefund creates a pending refund for a payment the caller has locked within repo's transaction.
+// An amount of 0 refunds everything that has not been refunded yet.
+func RequestRefund(ctx context.Context, repo Repository, payment *models.Payment, amount float64, reason string, actorID uuid.UUID) (*models.Refund, error) {
+	if !payment.IsRefundable() {
+		return nil, apperror.Conflict(fmt.Sprintf("payment with status %s cannot be refunded", payment.Status))
+	}
+
+	// Pending refunds already reserve part of the payment
+	reserved, err := repo.SumAmount(ctx, payment.ID, models.RefundStatusPending, models.RefundStatusCompleted)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := roundAmount(payment.Amount - reserved)
+	if remaining <= 0 {
+		return nil, apperror.Conflict("payment has already been fully refunded")
+	}
+
+	amount = roundAmount(amount)
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount > remaining {
+		return nil, apperror.InvalidInput(fmt.Sprintf("refund amount exceeds refundable amount of %.2f", remaining))
+	}
+
+	refund := &models.Refund{
+		PaymentID:   payment.ID,
+		OrderID:     payment.OrderID,
+		Amount:      amount,
+		Reason:      reason,
+		Status:      models.RefundStatusPending,
+		RequestedBy: actorID,
+	}
+
+	if err := repo.Create(ctx, refund); err != nil {
+		return nil, err
+	}
+
+	kind := "Partial"
+	if amount == roundAmount(payment.Amount) {
+		kind = "Full"
+	}
+	if err := repo.LogAudit(ctx, actorID, "REFUND_REQUESTED", "Refund", refund.ID, fmt.Sprintf("%s refund of %.2f for payment %s: %s", kind, amount, payment.PaymentNumber, reason)); err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// CompleteRefund marks a pending refund as paid out and updates the payment status
+func (s *Service) CompleteRefund(ctx context.Context, refundID, adminID uuid.UUID, req *ProcessRefundRequest) (*models.Refund, error) {
+	return s.process(ctx, refundID, adminID, models.RefundStatusCompleted, req.Notes)
+}
+
+// FailRefund marks a pending refund as failed, releasing its amount
+func (s *Service) FailRefund(ctx context.Context, refundID, adminID uuid.UUID, req *ProcessRefundRequest) (*models.Refund, error) {
+	return s.process(ctx, refundID, adminID, models.RefundStatusFailed, req.Notes)
+}
+
+func (s *Service) process(ctx context.Context, refundID, adminID uuid.UUID, status models.RefundStatus, notes string) (*models.Refund, error) {
+	var refund *models.Refund
+	err := s.repo.Transaction(ctx, func(repo Repository) error {
+		var err error
+		refund, err = repo.FindByIDForUpdate(ctx, refundID)
+		if err != nil {
+			return apperror.MapNotFound(err, ErrRefundNotFound)
+		}
+
+		payment, err := repo.FindPaymentForUpdate(ctx, refund.PaymentID)
+		if err != nil {
+			return apperror.MapNotFound(err, ErrPaymentNotFound)
+		}
+
+		if err := refund.MarkAsProcessed(adminID, status, notes); err != nil {
+			return err
+		}
+		if err := repo.Update(ctx, refund); err != nil {
+			return err
+		}
+
+		action := "REFUND_FAILED"
+		if status == models.RefundStatusCompleted {
+			action = "REFUND_COMPLETED"
+
+			refunded, err := repo.SumAmount(ctx, payment.ID, models.RefundStatusCompleted)
+			if err != nil {
+				return err
+			}
+			if err := payment.MarkAsRefunded(roundAmount(payment.Amount-refunded) <= 0); err != nil {
+				return err
+			}
+			if err := repo.UpdatePayment(ctx, payment); err != nil {
+				return err
+			}
+		}
+
+		return repo.LogAudit(ctx, adminID, action, "Refund", refund.ID, fmt.Sprintf("Refund %s of %.2f marked as %s", refund.RefundNumber, refund.Amount, status))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// roundAmount rounds money to 2 decimals to match decimal(12,2) columns
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
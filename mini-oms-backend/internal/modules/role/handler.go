@@ -0,0 +1,109 @@
+package role
+
+import (
+	"mini-oms-backend/internal/policy"
+	"mini-oms-backend/internal/utils"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetAll returns every role with its permissions (roles:manage)
+func (h *Handler) GetAll(c echo.Context) error {
+	roles, err := h.service.GetAll(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Roles retrieved successfully", roles)
+}
+
+// GetPermissions returns the permissions that can be granted to roles (roles:manage)
+func (h *Handler) GetPermissions(c echo.Context) error {
+	return utils.SuccessResponse(c, http.StatusOK, "Permissions retrieved successfully", h.service.Permissions())
+}
+
+// GetByName returns a role (roles:manage)
+func (h *Handler) GetByName(c echo.Context) error {
+	role, err := h.service.GetByName(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Role retrieved successfully", role)
+}
+
+// Create creates a staff role (roles:manage)
+// @Summary Create role
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param request body CreateRoleRequest true "Role"
+// @Success 201 {object} utils.APIResponse
+// @Router /api/admin/roles [post]
+func (h *Handler) Create(c echo.Context) error {
+	var req CreateRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	actor := policy.ActorFromContext(c)
+	role, err := h.service.Create(c.Request().Context(), actor, &req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogInfo(c.Request().Context(), "RoleService", role.Name, "CreateRole", "Role created by "+actor.UserID.String())
+	return utils.SuccessResponse(c, http.StatusCreated, "Role created successfully", role)
+}
+
+// Update replaces the permissions of a role and logs its users out (roles:manage)
+// @Summary Update role
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param name path string true "Role name"
+// @Param request body UpdateRoleRequest true "Role"
+// @Success 200 {object} utils.APIResponse
+// @Router /api/admin/roles/{name} [put]
+func (h *Handler) Update(c echo.Context) error {
+	var req UpdateRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return utils.ValidationFailedResponse(c, err)
+	}
+
+	actor := policy.ActorFromContext(c)
+	role, err := h.service.Update(c.Request().Context(), actor, c.Param("name"), &req)
+	if err != nil {
+		return err
+	}
+
+	utils.LogInfo(c.Request().Context(), "RoleService", role.Name, "UpdateRole", "Role updated by "+actor.UserID.String())
+	return utils.SuccessResponse(c, http.StatusOK, "Role updated successfully", role)
+}
+
+// Delete deletes an unassigned staff role (roles:manage)
+func (h *Handler) Delete(c echo.Context) error {
+	actor := policy.ActorFromContext(c)
+	if err := h.service.Delete(c.Request().Context(), actor, c.Param("name")); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Role deleted successfully", nil)
+}
@@ -0,0 +1,115 @@
+package role
+
+import (
+	"context"
+	"mini-oms-backend/internal/memstore"
+	"mini-oms-backend/internal/models"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// memoryRepository implements Repository on top of memstore, for tests
+type memoryRepository struct {
+	store *memstore.Store
+}
+
+func NewMemoryRepository(store *memstore.Store) Repository {
+	return &memoryRepository{store: store}
+}
+
+func (r *memoryRepository) Transaction(ctx context.Context, fn func(repo Repository) error) error {
+	return r.store.Transaction(ctx, func() error {
+		return fn(r)
+	})
+}
+
+func (r *memoryRepository) FindAll(ctx context.Context) ([]models.Role, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	roles := make([]models.Role, 0, len(r.store.Roles))
+	for _, role := range r.store.Roles {
+		role.Permissions = slices.Clone(role.Permissions)
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, nil
+}
+
+func (r *memoryRepository) FindByName(ctx context.Context, name string) (*models.Role, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	role, ok := r.store.Roles[name]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	role.Permissions = slices.Clone(role.Permissions)
+	return &role, nil
+}
+
+func (r *memoryRepository) Create(ctx context.Context, role *models.Role) error {
+	now := time.Now()
+	role.CreatedAt = now
+	role.UpdatedAt = now
+	return r.save(role)
+}
+
+func (r *memoryRepository) Update(ctx context.Context, role *models.Role) error {
+	role.UpdatedAt = time.Now()
+	return r.save(role)
+}
+
+func (r *memoryRepository) save(role *models.Role) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	row := *role
+	row.Permissions = slices.Clone(role.Permissions)
+	slices.Sort(row.Permissions)
+	r.store.Roles[role.Name] = row
+	return nil
+}
+
+func (r *memoryRepository) Delete(ctx context.Context, name string) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	delete(r.store.Roles, name)
+	return nil
+}
+
+func (r *memoryRepository) CountUsers(ctx context.Context, name string) (int64, error) {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	var count int64
+	for _, user := range r.store.Users {
+		if user.Role == name {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryRepository) RevokeUserTokens(ctx context.Context, name string) error {
+	r.store.Lock()
+	defer r.store.Unlock()
+
+	now := time.Now()
+	for id, token := range r.store.RefreshTokens {
+		if token.RevokedAt == nil && r.store.Users[token.UserID].Role == name {
+			token.RevokedAt = &now
+			r.store.RefreshTokens[id] = token
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepository) LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error {
+	return r.store.LogAudit(ctx, userID, action, entityName, entityID, details)
+}
@@ -0,0 +1,128 @@
+package role
+
+import (
+	"context"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Repository is the role data access used by Service. Roles are returned with their permissions.
+type Repository interface {
+	// Transaction runs fn as a unit of work; repo is bound to the transaction
+	Transaction(ctx context.Context, fn func(repo Repository) error) error
+
+	FindAll(ctx context.Context) ([]models.Role, error)
+	FindByName(ctx context.Context, name string) (*models.Role, error)
+	// Create and Update also store role.Permissions, replacing the previous ones
+	Create(ctx context.Context, role *models.Role) error
+	Update(ctx context.Context, role *models.Role) error
+	Delete(ctx context.Context, name string) error
+	CountUsers(ctx context.Context, name string) (int64, error)
+	// RevokeUserTokens revokes every active session of the users holding the role
+	RevokeUserTokens(ctx context.Context, name string) error
+
+	LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error
+}
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) Transaction(ctx context.Context, fn func(repo Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&gormRepository{db: tx})
+	})
+}
+
+// FindAll lists roles by name
+func (r *gormRepository) FindAll(ctx context.Context) ([]models.Role, error) {
+	var roles []models.Role
+	if err := r.db.WithContext(ctx).Order("name ASC").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	var grants []models.RolePermission
+	if err := r.db.WithContext(ctx).Order("permission ASC").Find(&grants).Error; err != nil {
+		return nil, err
+	}
+
+	permissions := make(map[string][]string)
+	for _, grant := range grants {
+		permissions[grant.RoleName] = append(permissions[grant.RoleName], grant.Permission)
+	}
+	for i := range roles {
+		roles[i].Permissions = permissions[roles[i].Name]
+	}
+	return roles, nil
+}
+
+func (r *gormRepository) FindByName(ctx context.Context, name string) (*models.Role, error) {
+	var role models.Role
+	if err := r.db.WithContext(ctx).First(&role, "name = ?", name).Error; err != nil {
+		return nil, err
+	}
+	err := r.db.WithContext(ctx).Model(&models.RolePermission{}).
+		Where("role_name = ?", name).Order("permission ASC").Pluck("permission", &role.Permissions).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *gormRepository) Create(ctx context.Context, role *models.Role) error {
+	if err := r.db.WithContext(ctx).Create(role).Error; err != nil {
+		return err
+	}
+	return r.insertPermissions(ctx, role)
+}
+
+func (r *gormRepository) Update(ctx context.Context, role *models.Role) error {
+	if err := r.db.WithContext(ctx).Save(role).Error; err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Delete(&models.RolePermission{}, "role_name = ?", role.Name).Error; err != nil {
+		return err
+	}
+	return r.insertPermissions(ctx, role)
+}
+
+func (r *gormRepository) insertPermissions(ctx context.Context, role *models.Role) error {
+	if len(role.Permissions) == 0 {
+		return nil
+	}
+	grants := make([]models.RolePermission, len(role.Permissions))
+	for i, permission := range role.Permissions {
+		grants[i] = models.RolePermission{RoleName: role.Name, Permission: permission}
+	}
+	return r.db.WithContext(ctx).Create(&grants).Error
+}
+
+// Delete removes a role; its permissions are removed by the foreign key cascade
+func (r *gormRepository) Delete(ctx context.Context, name string) error {
+	return r.db.WithContext(ctx).Delete(&models.Role{}, "name = ?", name).Error
+}
+
+// CountUsers counts accounts with the role, including soft-deleted ones since they still reference it
+func (r *gormRepository) CountUsers(ctx context.Context, name string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.User{}).Where("role = ?", name).Count(&count).Error
+	return count, err
+}
+
+func (r *gormRepository) RevokeUserTokens(ctx context.Context, name string) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("revoked_at IS NULL AND user_id IN (?)", r.db.Model(&models.User{}).Select("id").Where("role = ?", name)).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *gormRepository) LogAudit(ctx context.Context, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error {
+	return utils.LogAudit(r.db.WithContext(ctx), userID, action, entityName, entityID, details)
+}
@@ -0,0 +1,180 @@
+package role
+
+import (
+	"context"
+	"errors"
+	"mini-oms-backend/internal/apperror"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/policy"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+var (
+	ErrRoleNotFound = apperror.NotFound("role not found")
+	ErrRoleExists   = apperror.Conflict("role already exists")
+	ErrRoleInUse    = apperror.Conflict("role is still assigned to users")
+	ErrSystemRole   = apperror.Conflict("system roles cannot be deleted")
+	// ErrAdminRole is returned when changing the admin role, which always holds every permission
+	ErrAdminRole = apperror.Conflict("the admin role cannot be changed")
+	// ErrNotGrantable is returned when the actor would grant or revoke permissions they do not hold
+	ErrNotGrantable = apperror.Forbidden("cannot change permissions you do not hold")
+)
+
+// roleName is the allowed format of role names, e.g. catalog_manager
+var roleName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,min=2,max=50"`
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions" validate:"unique"`
+}
+
+type UpdateRoleRequest struct {
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions" validate:"unique"`
+}
+
+func (s *Service) GetAll(ctx context.Context) ([]models.Role, error) {
+	return s.repo.FindAll(ctx)
+}
+
+func (s *Service) GetByName(ctx context.Context, name string) (*models.Role, error) {
+	role, err := s.repo.FindByName(ctx, name)
+	if err != nil {
+		return nil, apperror.MapNotFound(err, ErrRoleNotFound)
+	}
+	return role, nil
+}
+
+// Permissions lists the permissions that can be granted to roles
+func (s *Service) Permissions() []policy.Permission {
+	return policy.Permissions
+}
+
+func (s *Service) Create(ctx context.Context, actor policy.Actor, req *CreateRoleRequest) (*models.Role, error) {
+	if !roleName.MatchString(req.Name) {
+		return nil, apperror.InvalidInput("role name must be lowercase letters, digits and underscores, starting with a letter")
+	}
+	if err := checkPermissions(actor, req.Permissions); err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{
+		Name:        req.Name,
+		Description: strings.TrimSpace(req.Description),
+		Permissions: req.Permissions,
+	}
+
+	err := s.repo.Transaction(ctx, func(repo Repository) error {
+		if _, err := repo.FindByName(ctx, role.Name); err == nil {
+			return ErrRoleExists
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := repo.Create(ctx, role); err != nil {
+			return err
+		}
+		return repo.LogAudit(ctx, actor.UserID, "ROLE_CREATED", "Role", uuid.Nil, "Role "+role.Name+" created with permissions: "+describe(role.Permissions))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetByName(ctx, role.Name)
+}
+
+// Update replaces the description and permissions of a role. Sessions of users holding the
+// role are revoked, since their access tokens still carry the old permissions.
+func (s *Service) Update(ctx context.Context, actor policy.Actor, name string, req *UpdateRoleRequest) (*models.Role, error) {
+	if name == models.RoleAdmin {
+		return nil, ErrAdminRole
+	}
+	if err := checkPermissions(actor, req.Permissions); err != nil {
+		return nil, err
+	}
+
+	err := s.repo.Transaction(ctx, func(repo Repository) error {
+		role, err := repo.FindByName(ctx, name)
+		if err != nil {
+			return apperror.MapNotFound(err, ErrRoleNotFound)
+		}
+		if !actor.CanGrant(role.Permissions) {
+			return ErrNotGrantable
+		}
+
+		role.Description = strings.TrimSpace(req.Description)
+		role.Permissions = req.Permissions
+		if err := repo.Update(ctx, role); err != nil {
+			return err
+		}
+		if err := repo.RevokeUserTokens(ctx, role.Name); err != nil {
+			return err
+		}
+		return repo.LogAudit(ctx, actor.UserID, "ROLE_UPDATED", "Role", uuid.Nil, "Permissions of role "+role.Name+" set to: "+describe(role.Permissions))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetByName(ctx, name)
+}
+
+// Delete removes a role that is neither built in nor assigned to any user
+func (s *Service) Delete(ctx context.Context, actor policy.Actor, name string) error {
+	return s.repo.Transaction(ctx, func(repo Repository) error {
+		role, err := repo.FindByName(ctx, name)
+		if err != nil {
+			return apperror.MapNotFound(err, ErrRoleNotFound)
+		}
+		if role.IsSystem {
+			return ErrSystemRole
+		}
+		if !actor.CanGrant(role.Permissions) {
+			return ErrNotGrantable
+		}
+
+		users, err := repo.CountUsers(ctx, name)
+		if err != nil {
+			return err
+		}
+		if users > 0 {
+			return ErrRoleInUse
+		}
+
+		if err := repo.Delete(ctx, name); err != nil {
+			return err
+		}
+		return repo.LogAudit(ctx, actor.UserID, "ROLE_DELETED", "Role", uuid.Nil, "Role "+name+" deleted")
+	})
+}
+
+// checkPermissions rejects unknown permissions and those the actor does not hold.
+// The wildcard is not grantable; it belongs to the admin role only.
+func checkPermissions(actor policy.Actor, permissions []string) error {
+	for _, permission := range permissions {
+		if !policy.IsValidPermission(permission) {
+			return apperror.InvalidInput("unknown permission: " + permission)
+		}
+	}
+	if !actor.CanGrant(permissions) {
+		return ErrNotGrantable
+	}
+	return nil
+}
+
+func describe(permissions []string) string {
+	if len(permissions) == 0 {
+		return "none"
+	}
+	return strings.Join(permissions, ", ")
+}
@@ -0,0 +1,98 @@
+package role
+
+import (
+	"context"
+	"errors"
+	"mini-oms-backend/internal/memstore"
+	"mini-oms-backend/internal/models"
+	"mini-oms-backend/internal/policy"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestService() (*Service, *memstore.Store) {
+	store := memstore.New()
+	return NewService(NewMemoryRepository(store)), store
+}
+
+func TestUpdateRoleRevokesSessionsOfItsUsers(t *testing.T) {
+	service, store := newTestService()
+	ctx := context.Background()
+	admin := policy.Admin(uuid.New())
+
+	if _, err := service.Create(ctx, admin, &CreateRoleRequest{Name: "support", Permissions: []string{policy.PermOrdersRead}}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	staff := models.User{ID: uuid.New(), Email: "cs@example.com", Role: "support"}
+	customer := models.User{ID: uuid.New(), Email: "buyer@example.com", Role: models.RoleUser}
+	store.Users[staff.ID] = staff
+	store.Users[customer.ID] = customer
+	staffToken := models.RefreshToken{ID: uuid.New(), UserID: staff.ID, ExpiresAt: time.Now().Add(time.Hour)}
+	customerToken := models.RefreshToken{ID: uuid.New(), UserID: customer.ID, ExpiresAt: time.Now().Add(time.Hour)}
+	store.RefreshTokens[staffToken.ID] = staffToken
+	store.RefreshTokens[customerToken.ID] = customerToken
+
+	role, err := service.Update(ctx, admin, "support", &UpdateRoleRequest{Permissions: []string{policy.PermOrdersRead, policy.PermOrdersCancel}})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if !slices.Equal(role.Permissions, []string{policy.PermOrdersCancel, policy.PermOrdersRead}) {
+		t.Errorf("permissions = %v", role.Permissions)
+	}
+
+	// Old access tokens carry the old permissions, so holders must log in again
+	if store.RefreshTokens[staffToken.ID].RevokedAt == nil {
+		t.Error("session of support user not revoked")
+	}
+	if store.RefreshTokens[customerToken.ID].RevokedAt != nil {
+		t.Error("session of other user revoked")
+	}
+}
+
+func TestRolePermissionsCannotExceedActor(t *testing.T) {
+	service, _ := newTestService()
+	ctx := context.Background()
+	manager := policy.Actor{UserID: uuid.New(), Role: "hr", Permissions: []string{policy.PermRolesManage, policy.PermOrdersRead}}
+
+	if _, err := service.Create(ctx, manager, &CreateRoleRequest{Name: "viewer", Permissions: []string{policy.PermOrdersRead}}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := service.Create(ctx, manager, &CreateRoleRequest{Name: "cashier", Permissions: []string{policy.PermPaymentsVerify}}); !errors.Is(err, ErrNotGrantable) {
+		t.Fatalf("err = %v, want ErrNotGrantable", err)
+	}
+	if _, err := service.Create(ctx, policy.Admin(uuid.New()), &CreateRoleRequest{Name: "root", Permissions: []string{policy.PermAll}}); err == nil {
+		t.Fatal("wildcard permission granted to a new role")
+	}
+	if _, err := service.Update(ctx, policy.Admin(uuid.New()), models.RoleAdmin, &UpdateRoleRequest{}); !errors.Is(err, ErrAdminRole) {
+		t.Fatalf("err = %v, want ErrAdminRole", err)
+	}
+}
+
+func TestDeleteRole(t *testing.T) {
+	service, store := newTestService()
+	ctx := context.Background()
+	admin := policy.Admin(uuid.New())
+
+	if err := service.Delete(ctx, admin, models.RoleUser); !errors.Is(err, ErrSystemRole) {
+		t.Fatalf("system role: err = %v, want ErrSystemRole", err)
+	}
+
+	service.Create(ctx, admin, &CreateRoleRequest{Name: "fulfillment", Permissions: []string{policy.PermOrdersFulfill}})
+	staff := models.User{ID: uuid.New(), Role: "fulfillment"}
+	store.Users[staff.ID] = staff
+	if err := service.Delete(ctx, admin, "fulfillment"); !errors.Is(err, ErrRoleInUse) {
+		t.Fatalf("assigned role: err = %v, want ErrRoleInUse", err)
+	}
+
+	delete(store.Users, staff.ID)
+	if err := service.Delete(ctx, admin, "fulfillment"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := service.GetByName(ctx, "fulfillment"); !errors.Is(err, ErrRoleNotFound) {
+		t.Fatalf("err = %v, want ErrRoleNotFound", err)
+	}
+}
@@ -0,0 +1,44 @@
+package policy
+
+import "slices"
+
+// Permissions granted to roles (stored in role_permissions) and checked by
+// middlewares.RequirePermission and the services
+const (
+	PermAll               = "*" // every permission; reserved for the admin role
+	PermCatalogWrite      = "catalog:write"
+	PermOrdersRead        = "orders:read"
+	PermOrdersCancel      = "orders:cancel"
+	PermOrdersFulfill     = "orders:fulfill"
+	PermPaymentsVerify    = "payments:verify"
+	PermRefundsManage     = "refunds:manage"
+	PermStatsRead         = "stats:read"
+	PermInvitationsManage = "invitations:manage"
+	PermRolesManage       = "roles:manage"
+	PermUsersManage       = "users:manage"
+)
+
+// Permission describes a permission that can be granted to a role
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Permissions lists every grantable permission
+var Permissions = []Permission{
+	{PermCatalogWrite, "Create, update and delete categories and products"},
+	{PermOrdersRead, "View and list the orders of all users"},
+	{PermOrdersCancel, "Cancel paid orders and reopen canceled ones"},
+	{PermOrdersFulfill, "Ship and complete paid orders"},
+	{PermPaymentsVerify, "Verify and reject payments"},
+	{PermRefundsManage, "Request and process refunds"},
+	{PermStatsRead, "View the admin dashboard stats"},
+	{PermInvitationsManage, "Invite, list and revoke admin invitations"},
+	{PermRolesManage, "Manage roles and assign them to users"},
+	{PermUsersManage, "Unlock accounts locked after failed logins"},
+}
+
+// IsValidPermission reports whether name can be granted to a role
+func IsValidPermission(name string) bool {
+	return slices.ContainsFunc(Permissions, func(p Permission) bool { return p.Name == name })
+}
@@ -8,6 +8,7 @@ import (
 )
 
 // LogAudit records an audit log entry. Can be used within a transaction (tx).
+// The request ID is taken from the context bound with db.WithContext.
 func LogAudit(db *gorm.DB, userID uuid.UUID, action, entityName string, entityID uuid.UUID, details string) error {
 	log := models.AuditLog{
 		UserID:     userID,
@@ -15,6 +16,7 @@ func LogAudit(db *gorm.DB, userID uuid.UUID, action, entityName string, entityID
 		EntityName: entityName,
 		EntityID:   entityID,
 		Details:    details,
+		RequestID:  RequestIDFromContext(db.Statement.Context),
 	}
 	return db.Create(&log).Error
 }
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"errors"
+	"mini-oms-backend/internal/apperror"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+// HTTPErrorHandler renders errors returned by handlers and middlewares (registered as
+// echo.Echo.HTTPErrorHandler). Domain errors keep their message and get the status and
+// code of their kind; anything else is logged and reported as a generic 500.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	var (
+		httpErr     *echo.HTTPError
+		fieldErrors validator.ValidationErrors
+		detailer    apperror.Detailer
+	)
+
+	var respErr error
+	switch {
+	case errors.As(err, &fieldErrors):
+		respErr = ValidationFailedResponse(c, err)
+
+	case errors.As(err, &httpErr):
+		message := http.StatusText(httpErr.Code)
+		if msg, ok := httpErr.Message.(string); ok && msg != "" {
+			message = msg
+		}
+		respErr = ErrorResponse(c, httpErr.Code, message)
+
+	default:
+		status, code, ok := apperror.Lookup(err)
+		if !ok {
+			// Errors caused by a timed out or canceled request are answered with 504/503 without noise
+			if c.Request().Context().Err() == nil {
+				LogError(c.Request().Context(), "HTTPErrorHandler", "", c.Request().Method+" "+c.Path(), err, "Unhandled error")
+			}
+			respErr = CodedErrorResponse(c, status, code, "Internal server error", nil)
+			break
+		}
+
+		var details interface{}
+		if errors.As(err, &detailer) {
+			details = detailer.ErrorDetails()
+		}
+		var retry *apperror.RetryError
+		if errors.As(err, &retry) {
+			c.Response().Header().Set("Retry-After", strconv.Itoa(retry.Seconds()))
+		}
+		respErr = CodedErrorResponse(c, status, code, err.Error(), details)
+	}
+
+	if respErr != nil {
+		LogError(c.Request().Context(), "HTTPErrorHandler", "", "WriteResponse", respErr, "Failed to write error response")
+	}
+}
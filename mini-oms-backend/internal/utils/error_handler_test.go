@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mini-oms-backend/internal/apperror"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type stateError struct{ from string }
+
+func (e *stateError) Error() string        { return "cannot move from " + e.from }
+func (e *stateError) Is(target error) bool { return target == apperror.ErrInvalidTransition }
+func (e *stateError) ErrorDetails() map[string]interface{} {
+	return map[string]interface{}{"from": e.from}
+}
+
+func handleError(t *testing.T, err error) (int, APIResponse) {
+	t.Helper()
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+	HTTPErrorHandler(err, c)
+
+	var body APIResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return rec.Code, body
+}
+
+func TestHTTPErrorHandlerMapsDomainErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		code    string
+		message string
+	}{
+		{"not found", apperror.NotFound("order not found"), http.StatusNotFound, apperror.CodeNotFound, "order not found"},
+		{"wrapped", fmt.Errorf("%w: Laptop", apperror.InsufficientStock("insufficient stock for product")), http.StatusConflict, apperror.CodeInsufficientStock, "insufficient stock for product: Laptop"},
+		{"forbidden", apperror.Forbidden("access forbidden"), http.StatusForbidden, apperror.CodeForbidden, "access forbidden"},
+		{"echo", echo.ErrMethodNotAllowed, http.StatusMethodNotAllowed, apperror.CodeMethodNotAllowed, "Method Not Allowed"},
+		{"unknown", errors.New("pq: connection refused"), http.StatusInternalServerError, apperror.CodeInternal, "Internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := handleError(t, tt.err)
+			if status != tt.status || body.Code != tt.code || body.Message != tt.message {
+				t.Fatalf("got %d %s %q, want %d %s %q", status, body.Code, body.Message, tt.status, tt.code, tt.message)
+			}
+		})
+	}
+}
+
+func TestHTTPErrorHandlerIncludesErrorDetails(t *testing.T) {
+	status, body := handleError(t, &stateError{from: "shipped"})
+
+	if status != http.StatusConflict || body.Code != apperror.CodeInvalidTransition {
+		t.Fatalf("got %d %s, want 409 %s", status, body.Code, apperror.CodeInvalidTransition)
+	}
+	details, _ := body.Errors.(map[string]interface{})
+	if details["from"] != "shipped" {
+		t.Fatalf("errors = %v, want from=shipped", body.Errors)
+	}
+}
+
+func TestHTTPErrorHandlerSetsRetryAfter(t *testing.T) {
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec)
+
+	HTTPErrorHandler(apperror.RetryAfter(apperror.TooManyRequests("too many failed login attempts"), 1500*time.Millisecond), c)
+
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "2" {
+		t.Fatalf("got %d Retry-After=%q, want 429 Retry-After=2", rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
@@ -9,19 +9,25 @@ import (
 )
 
 type JWTClaims struct {
-	UserID uuid.UUID `json:"user_id"`
-	Email  string    `json:"email"`
-	Role   string    `json:"role"`
+	UserID      uuid.UUID `json:"user_id"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	Permissions []string  `json:"permissions,omitempty"` // Permissions of Role when the token was issued
+	SessionID   uuid.UUID `json:"sid"`                   // Refresh token family the access token belongs to
 	jwt.RegisteredClaims
 }
 
-// GenerateJWT generates JWT token for user
-func GenerateJWT(cfg *config.Config, userID uuid.UUID, email, role string) (string, error) {
+// GenerateJWT generates JWT token for user. Permissions are embedded so requests can be
+// authorized without a lookup; changing a role revokes the sessions of its users.
+func GenerateJWT(cfg *config.Config, userID uuid.UUID, email, role string, permissions []string, sessionID uuid.UUID) (string, error) {
 	claims := &JWTClaims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
+		UserID:      userID,
+		Email:       email,
+		Role:        role,
+		Permissions: permissions,
+		SessionID:   sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(cfg.JWTExpiryHours) * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -1,37 +1,100 @@
 package utils
 
 import (
-	"log"
-	"strconv"
+	"context"
+	"log/slog"
+	"os"
+	"strings"
 )
 
-func LogError(svcName, refNo, methodName string, err error, data ...string) {
-	str := svcName
-	if refNo != "" {
-		str += " , referenceNo :: " + refNo
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or "" when there is none
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
 	}
-	if methodName != "" {
-		str += " , error " + methodName
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// NewLogger builds the application logger. Format "json" writes one JSON object per
+// line (production), anything else writes human-readable key=value lines.
+func NewLogger(level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLogLevel(level)}
+
+	var handler slog.Handler
+	if strings.EqualFold(format, "json") {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	} else {
+		handler = slog.NewTextHandler(os.Stdout, opts)
 	}
-	if err != nil {
-		str += " :: " + err.Error()
+	return slog.New(contextHandler{handler})
+}
+
+// ParseLogLevel maps debug, info, warn and error to slog levels, defaulting to info
+func ParseLogLevel(level string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		return slog.LevelInfo
 	}
-	for i, s := range data {
-		str += ",\n Data " + strconv.Itoa(i+1) + " ::" + s
+	return l
+}
+
+// contextHandler adds the request ID from the record's context to every log line
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
 	}
-	log.Println(str)
+	return h.Handler.Handle(ctx, record)
 }
 
-func LogInfo(svcName, refNo, methodName string, data ...string) {
-	str := svcName
-	if refNo != "" {
-		str += " , referenceNo :: " + refNo
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// LogError logs a failed operation. refNo identifies the entity or user involved and
+// data carries free-form details.
+func LogError(ctx context.Context, svcName, refNo, methodName string, err error, data ...string) {
+	attrs := logAttrs(svcName, refNo, methodName)
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
 	}
+	slog.LogAttrs(ctx, slog.LevelError, logMessage(methodName, data), attrs...)
+}
+
+// LogInfo logs a completed or requested operation, see LogError
+func LogInfo(ctx context.Context, svcName, refNo, methodName string, data ...string) {
+	slog.LogAttrs(ctx, slog.LevelInfo, logMessage(methodName, data), logAttrs(svcName, refNo, methodName)...)
+}
+
+func logAttrs(svcName, refNo, methodName string) []slog.Attr {
+	attrs := []slog.Attr{slog.String("service", svcName)}
 	if methodName != "" {
-		str += " , " + methodName
+		attrs = append(attrs, slog.String("method", methodName))
 	}
-	for i, s := range data {
-		str += ",\n Data " + strconv.Itoa(i+1) + " ::" + s
+	if refNo != "" {
+		attrs = append(attrs, slog.String("reference_no", refNo))
+	}
+	return attrs
+}
+
+func logMessage(methodName string, data []string) string {
+	if len(data) == 0 {
+		return methodName
 	}
-	log.Println(str)
+	return strings.Join(data, "; ")
 }
@@ -1,12 +1,18 @@
 package utils
 
 import (
+	"context"
+	"errors"
+	"mini-oms-backend/internal/apperror"
+	"net/http"
+
 	"github.com/labstack/echo/v4"
 )
 
 // APIResponse is standard API response structure
 type APIResponse struct {
 	Success bool        `json:"success"`
+	Code    string      `json:"code,omitempty"` // Machine-readable error code, see apperror
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
 	Meta    interface{} `json:"meta,omitempty"`
@@ -32,19 +38,31 @@ func SuccessResponseWithMeta(c echo.Context, statusCode int, message string, dat
 	})
 }
 
-// ErrorResponse returns error response
+// ErrorResponse returns error response with the default code for statusCode
 func ErrorResponse(c echo.Context, statusCode int, message string) error {
-	return c.JSON(statusCode, APIResponse{
-		Success: false,
-		Message: message,
-	})
+	return CodedErrorResponse(c, statusCode, apperror.CodeForStatus(statusCode), message, nil)
 }
 
-// ValidationErrorResponse returns validation error response
+// ValidationErrorResponse returns a VALIDATION_FAILED error response with one message per field
 func ValidationErrorResponse(c echo.Context, statusCode int, message string, errors interface{}) error {
+	return CodedErrorResponse(c, statusCode, apperror.CodeValidationFailed, message, errors)
+}
+
+// CodedErrorResponse returns error response with an explicit code. If the request context
+// is already done, the failure is reported as a timeout (504) or cancellation (503)
+// instead, since the error the handler saw is then only a symptom of the aborted work.
+func CodedErrorResponse(c echo.Context, statusCode int, code, message string, details interface{}) error {
+	switch err := c.Request().Context().Err(); {
+	case errors.Is(err, context.DeadlineExceeded):
+		statusCode, code, message, details = http.StatusGatewayTimeout, apperror.CodeTimeout, "Request timed out", nil
+	case errors.Is(err, context.Canceled):
+		statusCode, code, message, details = http.StatusServiceUnavailable, apperror.CodeServiceUnavailable, "Request canceled", nil
+	}
+
 	return c.JSON(statusCode, APIResponse{
 		Success: false,
+		Code:    code,
 		Message: message,
-		Errors:  errors,
+		Errors:  details,
 	})
 }
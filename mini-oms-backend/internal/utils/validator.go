@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+// RequestValidator enforces the `validate` struct tags of request DTOs; it is registered
+// as echo.Echo.Validator so handlers call c.Validate(&req) after binding
+type RequestValidator struct {
+	validate *validator.Validate
+}
+
+func NewRequestValidator() *RequestValidator {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by their JSON name, e.g. items[0].quantity
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	return &RequestValidator{validate: validate}
+}
+
+// Validate implements echo.Validator
+func (v *RequestValidator) Validate(i interface{}) error {
+	return v.validate.Struct(i)
+}
+
+// ValidationFailedResponse writes a 400 with one message per invalid field. Errors that
+// did not come from the validator are reported as an invalid request body.
+func ValidationFailedResponse(c echo.Context, err error) error {
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	fields := make(map[string]string, len(fieldErrors))
+	for _, fe := range fieldErrors {
+		fields[fieldPath(fe)] = fieldMessage(fe)
+	}
+	return ValidationErrorResponse(c, http.StatusBadRequest, "Validation failed", fields)
+}
+
+// fieldPath drops the struct name from the namespace: CreateOrderRequest.items[0].quantity -> items[0].quantity
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	isString := fe.Kind() == reflect.String
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "unique":
+		return "must not contain duplicates"
+	case "min":
+		if isString {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("must contain at least %s item(s)", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if isString {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("must contain at most %s item(s)", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be greater than or equal to " + fe.Param()
+	case "lte":
+		return "must be less than or equal to " + fe.Param()
+	default:
+		return "is invalid"
+	}
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+type testItem struct {
+	SKU      string `json:"sku" validate:"required"`
+	Quantity int    `json:"quantity" validate:"gt=0"`
+}
+
+type testRequest struct {
+	Email string     `json:"email" validate:"required,email"`
+	Items []testItem `json:"items" validate:"required,min=1,unique=SKU,dive"`
+}
+
+func validationErrors(t *testing.T, req testRequest) (int, map[string]string) {
+	t.Helper()
+	e := echo.New()
+	e.Validator = NewRequestValidator()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec)
+
+	if err := c.Validate(&req); err == nil {
+		return http.StatusOK, nil
+	} else if err := ValidationFailedResponse(c, err); err != nil {
+		t.Fatalf("ValidationFailedResponse: %v", err)
+	}
+
+	var body struct {
+		Errors map[string]string `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return rec.Code, body.Errors
+}
+
+func TestValidationFailedResponseReportsJSONFieldPaths(t *testing.T) {
+	code, errs := validationErrors(t, testRequest{
+		Email: "not-an-email",
+		Items: []testItem{{SKU: "A", Quantity: 1}, {SKU: "B", Quantity: 0}},
+	})
+
+	if code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", code)
+	}
+	want := map[string]string{
+		"email":             "must be a valid email address",
+		"items[1].quantity": "must be greater than 0",
+	}
+	for field, msg := range want {
+		if errs[field] != msg {
+			t.Errorf("errors[%s] = %q, want %q", field, errs[field], msg)
+		}
+	}
+	if len(errs) != len(want) {
+		t.Errorf("errors = %v, want %v", errs, want)
+	}
+}
+
+func TestValidationFailedResponseRejectsDuplicates(t *testing.T) {
+	_, errs := validationErrors(t, testRequest{
+		Email: "user@example.com",
+		Items: []testItem{{SKU: "A", Quantity: 1}, {SKU: "A", Quantity: 2}},
+	})
+
+	if errs["items"] != "must not contain duplicates" {
+		t.Errorf("errors = %v, want duplicate items error", errs)
+	}
+}
+
+func TestRequestValidatorAcceptsValidRequest(t *testing.T) {
+	code, errs := validationErrors(t, testRequest{
+		Email: "user@example.com",
+		Items: []testItem{{SKU: "A", Quantity: 1}},
+	})
+
+	if code != http.StatusOK || errs != nil {
+		t.Errorf("status = %d errors = %v, want valid", code, errs)
+	}
+}
@@ -0,0 +1,98 @@
+-- Baseline schema (previously created by GORM AutoMigrate).
+-- IF NOT EXISTS lets databases created by AutoMigrate adopt versioned migrations.
+
+CREATE TABLE IF NOT EXISTS users (
+    id          uuid PRIMARY KEY,
+    name        varchar(255) NOT NULL,
+    email       varchar(255) NOT NULL,
+    password    varchar(255) NOT NULL,
+    role        varchar(20)  NOT NULL DEFAULT 'user',
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    deleted_at  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS categories (
+    id          uuid PRIMARY KEY,
+    name        varchar(255) NOT NULL,
+    description text,
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    deleted_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_categories_deleted_at ON categories (deleted_at);
+
+CREATE TABLE IF NOT EXISTS products (
+    id          uuid PRIMARY KEY,
+    category_id uuid REFERENCES categories (id),
+    name        varchar(255)   NOT NULL,
+    description text,
+    price       decimal(12, 2) NOT NULL,
+    stock       integer        NOT NULL DEFAULT 0,
+    image_url   varchar(500),
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    deleted_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products (category_id);
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at);
+
+CREATE TABLE IF NOT EXISTS orders (
+    id           uuid PRIMARY KEY,
+    user_id      uuid REFERENCES users (id),
+    order_number varchar(50),
+    total_amount decimal(12, 2) NOT NULL,
+    status       varchar(20) DEFAULT 'created',
+    notes        text,
+    created_at   timestamptz,
+    updated_at   timestamptz,
+    deleted_at   timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_order_number ON orders (order_number);
+CREATE INDEX IF NOT EXISTS idx_orders_deleted_at ON orders (deleted_at);
+
+CREATE TABLE IF NOT EXISTS order_items (
+    id            uuid PRIMARY KEY,
+    order_id      uuid           NOT NULL REFERENCES orders (id),
+    product_id    uuid           NOT NULL REFERENCES products (id),
+    product_name  varchar(255)   NOT NULL,
+    product_price decimal(12, 2) NOT NULL,
+    quantity      integer        NOT NULL,
+    subtotal      decimal(12, 2) NOT NULL,
+    created_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items (order_id);
+CREATE INDEX IF NOT EXISTS idx_order_items_product_id ON order_items (product_id);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id                uuid PRIMARY KEY,
+    order_id          uuid           NOT NULL REFERENCES orders (id),
+    payment_number    varchar(50)    NOT NULL,
+    amount            decimal(12, 2) NOT NULL,
+    payment_method    varchar(50)    NOT NULL,
+    status            varchar(20)    NOT NULL DEFAULT 'pending',
+    payment_proof_url varchar(500),
+    verified_by       uuid REFERENCES users (id),
+    verified_at       timestamptz,
+    notes             text,
+    created_at        timestamptz,
+    updated_at        timestamptz
+);
+-- The original unique index on payments.order_id is superseded by 0007_payment_resubmission
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_payment_number ON payments (payment_number);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id          uuid PRIMARY KEY,
+    user_id     uuid,
+    action      varchar(100),
+    entity_name varchar(100),
+    entity_id   uuid,
+    details     text,
+    created_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_id ON audit_logs (user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_entity_id ON audit_logs (entity_id);
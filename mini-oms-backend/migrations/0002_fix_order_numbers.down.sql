@@ -0,0 +1 @@
+-- Data fix only; the generated order numbers are kept.
@@ -0,0 +1,4 @@
+-- Replaces the startup fixer db.fixOrderNumbers: backfill orders created before order_number existed.
+UPDATE orders
+SET order_number = 'ORD-FIX-' || to_char(created_at, 'YYYYMMDD') || '-' || substr(id::text, 1, 8)
+WHERE order_number IS NULL OR order_number = '';
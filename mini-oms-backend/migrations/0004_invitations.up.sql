@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS invitations (
+    id          uuid PRIMARY KEY,
+    email       varchar(255) NOT NULL,
+    role        varchar(20)  NOT NULL DEFAULT 'admin',
+    token_hash  varchar(64)  NOT NULL,
+    invited_by  uuid         NOT NULL REFERENCES users (id),
+    expires_at  timestamptz  NOT NULL,
+    accepted_at timestamptz,
+    revoked_at  timestamptz,
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_invitations_email ON invitations (email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invitations_token_hash ON invitations (token_hash);
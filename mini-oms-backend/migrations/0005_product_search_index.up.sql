@@ -0,0 +1,3 @@
+-- Expression must match product.searchVector
+CREATE INDEX IF NOT EXISTS idx_products_search ON products
+    USING GIN (to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(description, '')));
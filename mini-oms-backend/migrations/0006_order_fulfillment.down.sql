@@ -0,0 +1,4 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS canceled_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS completed_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS shipped_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS tracking_number;
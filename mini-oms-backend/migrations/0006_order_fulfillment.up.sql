@@ -0,0 +1,4 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tracking_number varchar(100);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipped_at timestamptz;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS completed_at timestamptz;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS canceled_at timestamptz;
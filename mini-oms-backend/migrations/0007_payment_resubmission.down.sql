@@ -0,0 +1,4 @@
+-- Fails if an order already has several payment attempts
+DROP INDEX IF EXISTS idx_payments_order_active;
+DROP INDEX IF EXISTS idx_payments_order;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_order_id ON payments (order_id);
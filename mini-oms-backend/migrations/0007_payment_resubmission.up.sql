@@ -0,0 +1,4 @@
+-- One active (non-failed) payment per order, so a rejected payment can be resubmitted
+DROP INDEX IF EXISTS idx_payments_order_id;
+CREATE INDEX IF NOT EXISTS idx_payments_order ON payments (order_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_order_active ON payments (order_id) WHERE status <> 'failed';
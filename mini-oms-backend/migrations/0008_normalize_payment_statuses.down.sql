@@ -0,0 +1 @@
+-- Data fix only; 'success' is no longer a valid payment status.
@@ -0,0 +1,3 @@
+-- Older builds stored verified payments as 'success' and some orders carried payment statuses
+UPDATE payments SET status = 'paid', updated_at = NOW() WHERE status = 'success';
+UPDATE orders SET status = 'processing', updated_at = NOW() WHERE status IN ('paid', 'success');
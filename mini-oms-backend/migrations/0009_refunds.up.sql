@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS refunds (
+    id            uuid PRIMARY KEY,
+    payment_id    uuid           NOT NULL REFERENCES payments (id),
+    order_id      uuid           NOT NULL,
+    refund_number varchar(50)    NOT NULL,
+    amount        decimal(12, 2) NOT NULL,
+    reason        text,
+    status        varchar(20)    NOT NULL DEFAULT 'pending',
+    requested_by  uuid           NOT NULL,
+    processed_by  uuid,
+    processed_at  timestamptz,
+    notes         text,
+    created_at    timestamptz,
+    updated_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_refunds_payment_id ON refunds (payment_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_order_id ON refunds (order_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refunds_refund_number ON refunds (refund_number);
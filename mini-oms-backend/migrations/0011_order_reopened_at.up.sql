@@ -0,0 +1,2 @@
+-- Re-opened orders get a fresh payment window (see order.Repository.FindExpiredUnpaidForUpdate)
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS reopened_at timestamptz;
@@ -0,0 +1,3 @@
+-- Correlates audit entries with the X-Request-ID of the request that produced them
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS request_id varchar(64) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_audit_logs_request_id ON audit_logs (request_id);
@@ -0,0 +1,14 @@
+-- Responses to requests sent with an Idempotency-Key header, replayed for retries
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id            uuid PRIMARY KEY,
+    user_id       uuid         NOT NULL,
+    route         varchar(255) NOT NULL,
+    key           varchar(255) NOT NULL,
+    request_hash  varchar(64)  NOT NULL,
+    status_code   integer      NOT NULL DEFAULT 0,
+    response_body text,
+    expires_at    timestamptz  NOT NULL,
+    created_at    timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_keys_scope ON idempotency_keys (user_id, route, key);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
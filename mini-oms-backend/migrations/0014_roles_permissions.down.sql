@@ -0,0 +1,5 @@
+ALTER TABLE users DROP CONSTRAINT IF EXISTS fk_users_role;
+UPDATE users SET role = 'user' WHERE role NOT IN ('user', 'admin');
+ALTER TABLE users ALTER COLUMN role TYPE varchar(20);
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,39 @@
+-- Roles and the permissions they grant; users.role references a role by name
+CREATE TABLE IF NOT EXISTS roles (
+    name        varchar(50)  PRIMARY KEY,
+    description varchar(255) NOT NULL DEFAULT '',
+    is_system   boolean      NOT NULL DEFAULT false,
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_name  varchar(50)  NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    permission varchar(100) NOT NULL,
+    PRIMARY KEY (role_name, permission)
+);
+
+INSERT INTO roles (name, description, is_system, created_at, updated_at) VALUES
+    ('admin', 'Full access', true, NOW(), NOW()),
+    ('user', 'Customer account', true, NOW(), NOW()),
+    ('catalog_manager', 'Manages categories and products', false, NOW(), NOW()),
+    ('finance', 'Verifies payments, handles refunds and reads stats', false, NOW(), NOW()),
+    ('fulfillment', 'Ships and completes paid orders', false, NOW(), NOW()),
+    ('support', 'Looks up and cancels customer orders', false, NOW(), NOW())
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_name, permission) VALUES
+    ('admin', '*'),
+    ('catalog_manager', 'catalog:write'),
+    ('finance', 'orders:read'),
+    ('finance', 'payments:verify'),
+    ('finance', 'refunds:manage'),
+    ('finance', 'stats:read'),
+    ('fulfillment', 'orders:read'),
+    ('fulfillment', 'orders:fulfill'),
+    ('support', 'orders:read'),
+    ('support', 'orders:cancel')
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE users ALTER COLUMN role TYPE varchar(50);
+ALTER TABLE users ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles (name) ON UPDATE CASCADE;
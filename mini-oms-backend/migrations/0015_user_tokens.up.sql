@@ -0,0 +1,17 @@
+-- Existing accounts are treated as verified so requiring verification does not lock them out
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at timestamptz;
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+-- Single-use tokens mailed to users (password reset, email verification)
+CREATE TABLE IF NOT EXISTS user_tokens (
+    id         uuid PRIMARY KEY,
+    user_id    uuid         NOT NULL REFERENCES users (id),
+    purpose    varchar(30)  NOT NULL,
+    email      varchar(255) NOT NULL,
+    token_hash varchar(64)  NOT NULL,
+    expires_at timestamptz  NOT NULL,
+    used_at    timestamptz,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id ON user_tokens (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_tokens_token_hash ON user_tokens (token_hash);
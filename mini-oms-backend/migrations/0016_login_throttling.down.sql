@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS login_failures;
+ALTER TABLE users DROP COLUMN IF EXISTS locked_until;
@@ -0,0 +1,14 @@
+-- Accounts are locked temporarily after too many failed logins
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until timestamptz;
+
+-- Failed logins within the throttling window, per account (email) and client IP
+CREATE TABLE IF NOT EXISTS login_failures (
+    id         uuid PRIMARY KEY,
+    email      varchar(255) NOT NULL,
+    ip_address varchar(45)  NOT NULL,
+    cleared_at timestamptz,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_login_failures_email ON login_failures (email);
+CREATE INDEX IF NOT EXISTS idx_login_failures_ip_address ON login_failures (ip_address);
+CREATE INDEX IF NOT EXISTS idx_login_failures_created_at ON login_failures (created_at);
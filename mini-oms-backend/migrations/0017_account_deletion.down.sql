@@ -0,0 +1,3 @@
+-- Fails if an email was registered again after its account was deleted
+DROP INDEX IF EXISTS idx_users_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
@@ -0,0 +1,3 @@
+-- Deleted (soft-deleted) accounts no longer hold on to their email, so it can be registered again
+DROP INDEX IF EXISTS idx_users_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email) WHERE deleted_at IS NULL;
@@ -0,0 +1,2 @@
+-- Only the grant added by the up migration; other roles granted users:manage keep it
+DELETE FROM role_permissions WHERE role_name = 'support' AND permission = 'users:manage';
@@ -0,0 +1,4 @@
+-- Support staff can unlock accounts
+INSERT INTO role_permissions (role_name, permission)
+SELECT name, 'users:manage' FROM roles WHERE name = 'support'
+ON CONFLICT DO NOTHING;
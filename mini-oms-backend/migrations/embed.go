@@ -0,0 +1,10 @@
+// Package migrations embeds the versioned SQL migrations applied by db.Migrator.
+//
+// Files are named <version>_<name>.up.sql / <version>_<name>.down.sql, where version
+// is a zero-padded integer. Every migration runs inside its own transaction.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
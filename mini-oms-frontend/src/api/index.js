@@ -17,7 +17,7 @@ export const productAPI = {
 
 // Order API
 export const orderAPI = {
-    getAll: () => apiClient.get('/api/orders'),
+    getAll: (params) => apiClient.get('/api/orders', { params }),
     getById: (id) => apiClient.get(`/api/orders/${id}`),
     create: (data) => apiClient.post('/api/orders', data),
     cancel: (id) => apiClient.post(`/api/orders/${id}/cancel`),